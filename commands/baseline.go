@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/validator"
+	"github.com/spf13/cobra"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline of known findings",
+	Long: "The baseline records the known findings of a repository. Findings recorded in the baseline\n" +
+		"do not fail a validation run with --baseline, so stricter checks can be adopted before all\n" +
+		"existing findings are fixed.",
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:   "update <path>",
+	Short: "Regenerate the baseline from the current findings",
+	Long: "Validates all kustomizations below the path and records every finding that is not suppressed\n" +
+		"in the baseline file given with --baseline, by default " + report.BaselineFileName + " in the\n" +
+		"current directory. Entries of findings no longer found are removed.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := resolveOptions(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		file := opts.baselineFile
+		if file == "" {
+			file = report.BaselineFileName
+		}
+
+		v := validator.New(append(opts.validatorOptions(), validator.WithReporters(validator.ReporterFunc(printWarnings)))...)
+		rprt, err := v.Run(cmd.Context())
+		if err != nil {
+			return internalError("%w", err)
+		}
+		if toolErrors := rprt.ToolErrors(); toolErrors > 0 {
+			return internalError("%d kustomize build(s) could not be executed", toolErrors)
+		}
+
+		baseline, err := report.NewBaseline(file, rprt.Report)
+		if err != nil {
+			return internalError("failed to create baseline: %w", err)
+		}
+		if err := baseline.Write(file); err != nil {
+			return internalError("failed to write baseline: %w", err)
+		}
+		fmt.Printf("Recorded %d finding(s) of %d kustomization(s) in %s\n", rprt.Summary.Findings, rprt.Summary.Total, file)
+		return nil
+	},
+}
+
+func init() {
+	baselineCmd.AddCommand(baselineUpdateCmd)
+	RootCmd.AddCommand(baselineCmd)
+}
@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/diff"
+	"github.com/redhat-consulting-services/kustomize-validator/git"
+	"github.com/redhat-consulting-services/kustomize-validator/graph"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"github.com/spf13/cobra"
+)
+
+const formatMarkdown = "markdown"
+
+// diffWriters maps the formats of the diff command to their writers
+var diffWriters = map[string]func(io.Writer, *diff.Report) error{
+	outputText:     diff.WriteText,
+	formatMarkdown: diff.WriteMarkdown,
+	formatJSON:     diff.WriteJSON,
+}
+
+// revision is the validated path checked out at a git reference in a temporary worktree
+type revision struct {
+	ref      string
+	worktree string
+	// path is the validated path within the worktree
+	path  string
+	opts  *options
+	files []string
+	graph *graph.Graph
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <path>",
+	Short: "Compare the rendered resources of the kustomizations at two git references",
+	Long: "Checks out both references in temporary git worktrees and builds the kustomizations below\n" +
+		"the path affected by the files changed between them, or all kustomizations with --all.\n" +
+		"Rendered resources are matched by apiVersion, kind, namespace and name. Added and removed\n" +
+		"resources are listed, modified resources are printed as unified diff.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		from, _ := flags.GetString("from")
+		to, _ := flags.GetString("to")
+		format, _ := flags.GetString("format")
+		all, _ := flags.GetBool("all")
+		writeDiff, ok := diffWriters[format]
+		if !ok {
+			return internalError("unsupported diff format %q", format)
+		}
+		ctx := cmd.Context()
+
+		top, err := git.TopLevel(ctx, args[0])
+		if err != nil {
+			return internalError("%w", err)
+		}
+		abs, err := filepath.Abs(args[0])
+		if err == nil {
+			abs, err = filepath.EvalSymlinks(abs)
+		}
+		if err != nil {
+			return internalError("%w", err)
+		}
+		rel, err := filepath.Rel(top, abs)
+		if err != nil {
+			return internalError("%w", err)
+		}
+
+		var revisions []*revision
+		defer func() {
+			for _, r := range revisions {
+				git.RemoveWorktree(context.Background(), top, r.worktree)
+			}
+		}()
+		for _, ref := range []string{from, to} {
+			r, err := checkoutRevision(cmd, top, rel, ref)
+			if r != nil {
+				// the worktree exists even if the revision failed to resolve, it must be removed
+				revisions = append(revisions, r)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		dirs, err := diffDirs(ctx, top, revisions, all)
+		if err != nil {
+			fmt.Fprint(os.Stderr, validate.Warningf("failed to determine the changed kustomizations, comparing all kustomizations: %s", err))
+			dirs, _ = diffDirs(ctx, top, revisions, true)
+		}
+		builds := make([]map[string]diff.Build, len(revisions))
+		for i, r := range revisions {
+			builds[i], err = r.render(ctx, dirs)
+			if err != nil {
+				return err
+			}
+		}
+
+		rprt := &diff.Report{Path: args[0], From: from, To: to, Kustomizations: []diff.Kustomization{}}
+		buildFailures := 0
+		for _, dir := range dirs {
+			k := diff.Compare(dir, from, to, builds[0][dir], builds[1][dir])
+			if k.ToError != "" {
+				buildFailures++
+			}
+			rprt.Kustomizations = append(rprt.Kustomizations, k)
+		}
+		if err := writeDiff(os.Stdout, rprt); err != nil {
+			return internalError("failed to write diff: %w", err)
+		}
+		if buildFailures > 0 {
+			return &ExitError{Code: ExitBuildFailure, Err: fmt.Errorf("%d kustomize build(s) failed at %s", buildFailures, to)}
+		}
+		return nil
+	},
+}
+
+// checkoutRevision checks out the reference in a temporary worktree and discovers
+// the kustomizations below the validated path, at the relative path rel in the repository.
+// Once the worktree is added, the revision is returned even on error so it can be removed.
+func checkoutRevision(cmd *cobra.Command, top, rel, ref string) (*revision, error) {
+	worktree, err := git.AddWorktree(cmd.Context(), top, ref)
+	if err != nil {
+		return nil, internalError("failed to check out %s: %w", ref, err)
+	}
+	r := &revision{ref: ref, worktree: worktree, path: filepath.Join(worktree, rel)}
+	if _, err := os.Stat(r.path); err != nil {
+		// the validated path does not exist at the reference, all kustomizations are added or removed
+		return r, nil
+	}
+	// the configuration file and the ignore files of the reference apply
+	r.opts, err = resolveOptions(cmd, r.path)
+	if err != nil {
+		return r, err
+	}
+	// the graph contains the kustomizations not matching --include, so changed bases
+	// are traced to the included overlays, see diffDirs
+	r.files, _, err = validate.FindAllKustomizations(r.path, r.opts.build)
+	if err != nil {
+		return r, internalError("%w", err)
+	}
+	r.graph = graph.Build(r.path, r.files)
+	return r, nil
+}
+
+// diffDirs returns the directories of the kustomizations to compare relative to the
+// validated path, sorted. Unless all is set, only the kustomizations affected by the
+// files changed between the references are compared. Kustomizations not matching
+// --include are not compared.
+func diffDirs(ctx context.Context, top string, revisions []*revision, all bool) ([]string, error) {
+	var changed []string
+	if !all {
+		var err error
+		changed, err = git.DiffFiles(ctx, top, revisions[0].ref, revisions[1].ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	set := map[string]bool{}
+	for _, r := range revisions {
+		if r.graph == nil {
+			continue
+		}
+		dirs := r.graph.Dirs()
+		if !all {
+			// the changed files are located in the worktree of the reference
+			inWorktree := make([]string, 0, len(changed))
+			for _, file := range changed {
+				rel, err := filepath.Rel(top, file)
+				if err != nil {
+					return nil, err
+				}
+				inWorktree = append(inWorktree, filepath.Join(r.worktree, rel))
+			}
+			var err error
+			if dirs, err = r.graph.Affected(inWorktree); err != nil {
+				return nil, fmt.Errorf("%s: %w", r.ref, err)
+			}
+		}
+		for _, dir := range dirs {
+			if r.opts.build.Select != nil && !r.opts.build.Select(dir) {
+				continue
+			}
+			if rel, err := filepath.Rel(r.path, dir); err == nil {
+				set[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(set))
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// render builds the kustomizations in the given directories, relative to the validated path
+func (r *revision) render(ctx context.Context, dirs []string) (map[string]diff.Build, error) {
+	builds := map[string]diff.Build{}
+	if r.graph == nil {
+		return builds, nil
+	}
+	selected := map[string]bool{}
+	for _, dir := range dirs {
+		selected[filepath.Join(r.path, filepath.FromSlash(dir))] = true
+	}
+	var files []string
+	for _, file := range r.files {
+		if selected[filepath.Dir(file)] {
+			files = append(files, file)
+		}
+	}
+
+	for msg := range validate.BuildKustomizations(ctx, files, r.opts.build) {
+		if msg.IsToolError() {
+			return nil, internalError("kustomize build could not be executed: %w", msg.Err)
+		}
+		rel, err := filepath.Rel(r.path, msg.Path)
+		if err != nil {
+			return nil, internalError("%w", err)
+		}
+		build := diff.Build{Exists: true}
+		if msg.Err != nil {
+			build.Err = msg.Err
+			if stderr := strings.TrimSpace(msg.Stderr); stderr != "" {
+				build.Err = errors.New(stderr)
+			}
+		} else {
+			build.Resources = k8s.ParseKustomizeOutput(msg.Stdout, msg.Path, msg.OriginRoot, r.path)
+		}
+		builds[filepath.ToSlash(rel)] = build
+	}
+	return builds, nil
+}
+
+func init() {
+	diffCmd.Flags().String("from", "", "git reference of the old revision, e.g., origin/main")
+	diffCmd.Flags().String("to", "HEAD", "git reference of the new revision")
+	diffCmd.Flags().StringP("format", "f", outputText, "format of the diff, one of: text, markdown, json")
+	diffCmd.Flags().Bool("all", false, "compare all kustomizations instead of the ones affected by the changed files")
+	diffCmd.MarkFlagRequired("from")
+	RootCmd.AddCommand(diffCmd)
+}
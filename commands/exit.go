@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// Exit codes of the kustomize-validator command. If several apply, the
+// first one in the order internal error, build failure, timeout and
+// content finding is used.
+const (
+	// ExitOK is returned if all kustomizations built and validated successfully
+	ExitOK = 0
+	// ExitFinding is returned if findings at or above the --fail-on threshold were found
+	ExitFinding = 1
+	// ExitBuildFailure is returned if at least one kustomize build failed
+	ExitBuildFailure = 2
+	// ExitTimeout is returned if at least one kustomize build exceeded its timeout
+	ExitTimeout = 3
+	// ExitInternal is returned on internal or tool errors, e.g. a missing kustomize binary
+	ExitInternal = 4
+)
+
+const (
+	failOnError   = "error"
+	failOnWarning = "warning"
+	failOnNone    = "none"
+)
+
+// ExitError is returned by the commands to terminate with a specific exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitInternal
+}
+
+// internalError wraps the given error as an internal error
+func internalError(format string, a ...any) error {
+	return &ExitError{Code: ExitInternal, Err: fmt.Errorf(format, a...)}
+}
+
+// outcome derives the result of a validation run from its report. toolErrors is
+// the number of builds that failed because of the environment rather than the
+// kustomization, failOn the threshold above which content findings fail the run.
+func outcome(r *report.Report, toolErrors int, failOn string) error {
+	if toolErrors > 0 {
+		return internalError("%d kustomize build(s) could not be executed", toolErrors)
+	}
+
+	buildFailures, timeouts, findings := 0, 0, 0
+	for _, k := range r.Kustomizations {
+		switch k.Status {
+		case report.StatusBuildFailed:
+			buildFailures++
+		case report.StatusTimeout:
+			timeouts++
+		}
+		for _, f := range k.Findings {
+			if failsOn(f.Severity, failOn) {
+				findings++
+			}
+		}
+	}
+
+	switch {
+	case buildFailures > 0:
+		return &ExitError{Code: ExitBuildFailure, Err: fmt.Errorf("%d kustomize build(s) failed", buildFailures)}
+	case timeouts > 0:
+		return &ExitError{Code: ExitTimeout, Err: fmt.Errorf("%d kustomize build(s) timed out", timeouts)}
+	case findings > 0:
+		return &ExitError{Code: ExitFinding, Err: fmt.Errorf("%d finding(s) found", findings)}
+	}
+	return nil
+}
+
+// failsOn reports whether a finding of the given severity reaches the --fail-on threshold
+func failsOn(severity validate.Severity, failOn string) bool {
+	switch failOn {
+	case failOnNone:
+		return false
+	case failOnWarning:
+		return severity.AtLeast(validate.SeverityWarning)
+	default:
+		return severity.AtLeast(validate.SeverityError)
+	}
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+func Test_outcome(t *testing.T) {
+	finding := []report.Finding{{Pattern: "PATCH_ME"}}
+	warning := []report.Finding{{FieldPath: "kind", Severity: validate.SeverityWarning}}
+	tests := []struct {
+		name       string
+		statuses   []report.Status
+		findings   []report.Finding
+		toolErrors int
+		failOn     string
+		want       int
+	}{
+		{name: "success", statuses: []report.Status{report.StatusSuccess}, failOn: failOnError, want: ExitOK},
+		{name: "content finding", statuses: []report.Status{report.StatusValidationFailed}, findings: finding, failOn: failOnError, want: ExitFinding},
+		{name: "content finding below threshold", statuses: []report.Status{report.StatusValidationFailed}, findings: finding, failOn: failOnNone, want: ExitOK},
+		{name: "warning below threshold", statuses: []report.Status{report.StatusValidationFailed}, findings: warning, failOn: failOnError, want: ExitOK},
+		{name: "warning at threshold", statuses: []report.Status{report.StatusValidationFailed}, findings: warning, failOn: failOnWarning, want: ExitFinding},
+		{name: "build failure", statuses: []report.Status{report.StatusBuildFailed, report.StatusTimeout}, failOn: failOnNone, want: ExitBuildFailure},
+		{name: "timeout", statuses: []report.Status{report.StatusTimeout, report.StatusValidationFailed}, findings: finding, failOn: failOnError, want: ExitTimeout},
+		{name: "tool error", statuses: []report.Status{report.StatusBuildFailed}, toolErrors: 1, failOn: failOnError, want: ExitInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := report.New(".")
+			for _, status := range tt.statuses {
+				k := report.Kustomization{Status: status}
+				if status == report.StatusValidationFailed {
+					k.Findings = tt.findings
+				}
+				r.Add(k)
+			}
+			if got := ExitCode(outcome(r, tt.toolErrors, tt.failOn)); got != tt.want {
+				t.Errorf("expected exit code %d, got: %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/graph"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatDOT     = "dot"
+	formatMermaid = "mermaid"
+	formatJSON    = "json"
+)
+
+// graphWriters maps the formats of the graph command to their writers
+var graphWriters = map[string]func(io.Writer, *graph.Graph) error{
+	formatDOT:     graph.WriteDOT,
+	formatMermaid: graph.WriteMermaid,
+	formatJSON:    graph.WriteJSON,
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph <path>",
+	Short: "Print the dependency graph of the kustomizations",
+	Long: "Parses every kustomization below the path and prints the graph of the overlays, bases,\n" +
+		"components and remote resources they reference. Cycles, bases no kustomization references\n" +
+		"and references to paths outside of the path are highlighted in the graph and reported as\n" +
+		"warnings. Kustomizations are not built.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		writeGraph, ok := graphWriters[format]
+		if !ok {
+			return internalError("unsupported graph format %q", format)
+		}
+		opts, err := resolveOptions(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		// --include does not apply, the roles of the kustomizations depend on all of them
+		files, _, err := validate.FindAllKustomizations(opts.path, opts.build)
+		if err != nil {
+			return internalError("%w", err)
+		}
+		g := graph.Build(opts.path, files)
+		if err := writeGraph(os.Stdout, g); err != nil {
+			return internalError("failed to write graph: %w", err)
+		}
+
+		// the issues are written to stderr, so stdout can be piped into a renderer
+		for _, cycle := range g.Cycles() {
+			fmt.Fprint(os.Stderr, validate.Warningf("cycle between the kustomizations %s", joinRel(g, cycle)))
+		}
+		for _, dir := range g.UnreferencedBases() {
+			kind := graph.RoleBase
+			if g.Nodes[dir].Kind == graph.KindComponent {
+				kind = graph.RoleComponent
+			}
+			fmt.Fprint(os.Stderr, validate.Warningf("%s %s is not referenced by any kustomization", kind, g.Rel(dir)))
+		}
+		for _, edge := range g.Outside() {
+			fmt.Fprint(os.Stderr, validate.Warningf("%s references %s outside of %s in %s", g.Rel(edge.From), edge.Path, args[0], edge.Field))
+		}
+		for _, dir := range g.Dirs() {
+			if err := g.Nodes[dir].Err; err != nil {
+				fmt.Fprint(os.Stderr, validate.Warningf("%s", err))
+			}
+		}
+		return nil
+	},
+}
+
+// joinRel joins the directories relative to the root of the graph
+func joinRel(g *graph.Graph, dirs []string) string {
+	rel := make([]string, len(dirs))
+	for i, dir := range dirs {
+		rel[i] = g.Rel(dir)
+	}
+	return strings.Join(rel, ", ")
+}
+
+func init() {
+	graphCmd.Flags().StringP("format", "f", formatDOT, "format of the graph, one of: dot, mermaid, json")
+	RootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,346 @@
+package commands
+
+import (
+	"github.com/redhat-consulting-services/kustomize-validator/config"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/policy"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"github.com/redhat-consulting-services/kustomize-validator/validator"
+	"github.com/spf13/cobra"
+)
+
+// unknownKindNone is the --unknown-kind-severity disabling the findings of resources without schema
+const unknownKindNone = "none"
+
+// options are the settings of a validation run, resolved from the
+// command line flags and the project configuration file
+type options struct {
+	path      string
+	output    string
+	verbose   bool
+	errorOnly bool
+	// leavesOnly exempts kustomizations referenced by other kustomizations from the checks
+	leavesOnly bool
+	// changedSince is the git reference of --changed-since, empty to validate all kustomizations
+	changedSince string
+	failOn       string
+	checks       []string
+	// checksFromFlags reports whether the checks were set on the command line, the
+	// checks of the overrides of the configuration file do not replace them then
+	checksFromFlags bool
+	// rules are the checks of the named rules of the configuration file by their ID
+	rules map[string]validate.Check
+	// workloadChecks are the enabled built-in workload rules
+	workloadChecks []validate.Check
+	build          validate.BuildOptions
+	// schema validates rendered resources against the schemas of their kinds, nil if disabled
+	schema *schema.Validator
+	// unknownKind is the severity of resources without schema, empty if they are not reported
+	unknownKind validate.Severity
+	// admission evaluates the bound ValidatingAdmissionPolicies, nil if disabled
+	admission *policy.Admission
+	// baselineFile is the baseline of known findings, empty if none is used
+	baselineFile string
+	// config is the project configuration file, nil if there is none
+	config *config.Config
+}
+
+// resolveOptions resolves the options of a validation run of the given path.
+// The configuration file is either passed with --config or discovered by walking
+// up from the path. Flags set on the command line take precedence over the file.
+func resolveOptions(cmd *cobra.Command, path string) (*options, error) {
+	flags := cmd.Flags()
+	opts := &options{path: path}
+	opts.verbose, _ = flags.GetBool("verbose")
+	opts.errorOnly, _ = flags.GetBool("error-only")
+	opts.leavesOnly, _ = flags.GetBool("leaves-only")
+	opts.changedSince, _ = flags.GetString("changed-since")
+	opts.output, _ = flags.GetString("output")
+	opts.failOn, _ = flags.GetString("fail-on")
+	opts.checks, _ = flags.GetStringSlice("check")
+	opts.checksFromFlags = flags.Changed("check")
+	opts.build.Concurrency, _ = flags.GetInt("concurrency")
+	opts.build.Timeout, _ = flags.GetDuration("timeout")
+	if flags.Changed("build-flags") {
+		opts.build.BuildFlags, _ = flags.GetStringSlice("build-flags")
+	}
+
+	configFile, _ := flags.GetString("config")
+	if configFile == "" {
+		var err error
+		configFile, err = config.Discover(path)
+		if err != nil {
+			return nil, internalError("failed to discover configuration file: %w", err)
+		}
+	}
+	if configFile != "" {
+		cfg, err := config.Load(configFile)
+		if err != nil {
+			return nil, internalError("failed to load configuration file: %w", err)
+		}
+		opts.applyConfig(cmd, cfg)
+	}
+
+	engine, _ := flags.GetString("engine")
+	if opts.config != nil && !flags.Changed("engine") && opts.config.Engine != "" {
+		engine = opts.config.Engine
+	}
+	builder, err := validate.NewBuilder(engine)
+	if err != nil {
+		return nil, internalError("%w", err)
+	}
+	opts.build.Builder = builder
+	if err := validate.ValidateBuildFlags(engine, opts.build.BuildFlags); err != nil {
+		return nil, internalError("invalid --build-flags: %w", err)
+	}
+
+	// the exclude patterns of the flags and the configuration file apply both
+	exclude, _ := flags.GetStringSlice("exclude")
+	include, _ := flags.GetStringSlice("include")
+	filter, err := config.NewFilter(path, exclude, include, opts.config)
+	if err != nil {
+		return nil, internalError("%w", err)
+	}
+	opts.build.Skip = filter.Skip
+	opts.build.Select = filter.Select
+
+	checks := opts.checks
+	if opts.config != nil {
+		for _, override := range opts.config.Overrides {
+			checks = append(checks[:len(checks):len(checks)], override.Checks...)
+		}
+		if err := opts.resolveRules(opts.config.Rules); err != nil {
+			return nil, err
+		}
+	}
+	if err := validate.ValidateChecks(checks); err != nil {
+		return nil, internalError("%w", err)
+	}
+
+	workloadRules, _ := flags.GetStringSlice("rules")
+	if opts.config != nil && !flags.Changed("rules") && len(opts.config.WorkloadRules) > 0 {
+		workloadRules = opts.config.WorkloadRules
+	}
+	opts.workloadChecks, err = validate.SelectWorkloadChecks(workloadRules)
+	if err != nil {
+		return nil, internalError("invalid --rules: %w", err)
+	}
+
+	opts.baselineFile, _ = flags.GetString("baseline")
+	if opts.config != nil && !flags.Changed("baseline") {
+		opts.baselineFile = opts.config.BaselineFile()
+	}
+
+	if isTable, _ := flags.GetBool("table"); isTable {
+		opts.output = outputTable
+	}
+
+	validateSchema, _ := flags.GetBool("validate-schema")
+	kubernetesVersion, _ := flags.GetString("kubernetes-version")
+	if opts.config != nil && !flags.Changed("validate-schema") {
+		validateSchema = opts.config.ValidateSchema
+	}
+	if opts.config != nil && !flags.Changed("kubernetes-version") && opts.config.KubernetesVersion != "" {
+		kubernetesVersion = opts.config.KubernetesVersion
+	}
+	if validateSchema {
+		if err := opts.resolveSchema(cmd, kubernetesVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	validatePolicies, _ := flags.GetBool("validate-policies")
+	if opts.config != nil && !flags.Changed("validate-policies") {
+		validatePolicies = opts.config.ValidatePolicies
+	}
+	if validatePolicies {
+		if err := opts.resolveAdmission(cmd); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// resolveAdmission creates the evaluator of the ValidatingAdmissionPolicies with the
+// policies, bindings and params of the configured directories
+func (o *options) resolveAdmission(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	var err error
+	o.admission, err = policy.NewAdmission()
+	if err != nil {
+		return internalError("%w", err)
+	}
+
+	policyDirs, _ := flags.GetStringSlice("policy-dir")
+	if o.config != nil && !flags.Changed("policy-dir") {
+		policyDirs = o.config.PolicyDirs()
+	}
+	for _, dir := range policyDirs {
+		if err := o.admission.AddDir(dir); err != nil {
+			return internalError("failed to load ValidatingAdmissionPolicies: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveSchema creates the schema validator with the bundled schemas of the given
+// Kubernetes version and the CustomResourceDefinitions of the configured directories
+func (o *options) resolveSchema(cmd *cobra.Command, kubernetesVersion string) error {
+	flags := cmd.Flags()
+	var err error
+	o.schema, err = schema.Kubernetes(kubernetesVersion)
+	if err != nil {
+		return internalError("%w", err)
+	}
+
+	crdDirs, _ := flags.GetStringSlice("crd-dir")
+	if o.config != nil && !flags.Changed("crd-dir") {
+		crdDirs = o.config.CRDDirs()
+	}
+	for _, dir := range crdDirs {
+		if err := o.schema.AddCRDsFromDir(dir); err != nil {
+			return internalError("failed to load CustomResourceDefinitions: %w", err)
+		}
+	}
+
+	unknownKind, _ := flags.GetString("unknown-kind-severity")
+	if o.config != nil && !flags.Changed("unknown-kind-severity") && o.config.UnknownKindSeverity != "" {
+		unknownKind = o.config.UnknownKindSeverity
+	}
+	if unknownKind != unknownKindNone {
+		o.unknownKind, err = validate.ParseSeverity(unknownKind)
+		if err != nil {
+			return internalError("invalid --unknown-kind-severity: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveRules converts the named rules of the configuration file into checks. Rules
+// have either a check pattern or a CEL expression.
+func (o *options) resolveRules(rules []config.Rule) error {
+	o.rules = map[string]validate.Check{}
+	for i, rule := range rules {
+		switch {
+		case rule.ID == "":
+			return internalError("invalid rule %d in configuration file: missing id", i+1)
+		case rule.Description == "":
+			return internalError("invalid rule %s in configuration file: missing description", rule.ID)
+		case rule.Check == "" && rule.Expression == "":
+			return internalError("invalid rule %s in configuration file: missing check or expression", rule.ID)
+		case rule.Check != "" && rule.Expression != "":
+			return internalError("invalid rule %s in configuration file: check and expression are exclusive", rule.ID)
+		}
+		if _, ok := o.rules[rule.ID]; ok {
+			return internalError("invalid rule %s in configuration file: duplicate id", rule.ID)
+		}
+		severity, err := validate.ParseSeverity(rule.Severity)
+		if err != nil {
+			return internalError("invalid rule %s in configuration file: %w", rule.ID, err)
+		}
+		validationRule := validate.Rule{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Severity:    severity,
+			Remediation: rule.Remediation,
+			Check:       rule.Check,
+		}
+		if rule.Check != "" {
+			if err := validate.ValidateChecks([]string{rule.Check}); err != nil {
+				return internalError("invalid rule %s in configuration file: %w", rule.ID, err)
+			}
+			o.rules[rule.ID] = validate.RuleCheck{Rule: validationRule}
+			continue
+		}
+
+		celRule := policy.CELRule{Rule: validationRule, Expression: rule.Expression, Message: rule.Message}
+		if rule.FieldPath != "" {
+			if celRule.FieldPath, err = k8s.ParseFieldPath(rule.FieldPath); err != nil {
+				return internalError("invalid rule %s in configuration file: %w", rule.ID, err)
+			}
+		}
+		if rule.Match != nil {
+			celRule.Kinds = rule.Match.Kinds
+			celRule.Selector = rule.Match.LabelSelector
+		}
+		o.rules[rule.ID], err = policy.NewCELCheck(celRule)
+		if err != nil {
+			return internalError("invalid rule %s in configuration file: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// applyConfig applies the settings of the configuration file that were not set on the command line
+func (o *options) applyConfig(cmd *cobra.Command, cfg *config.Config) {
+	o.config = cfg
+	flags := cmd.Flags()
+	if !flags.Changed("check") && len(cfg.Checks) > 0 {
+		o.checks = cfg.Checks
+	}
+	if !flags.Changed("output") && cfg.Output != "" {
+		o.output = cfg.Output
+	}
+	if !flags.Changed("fail-on") && cfg.FailOn != "" {
+		o.failOn = cfg.FailOn
+	}
+	if !flags.Changed("leaves-only") && cfg.LeavesOnly {
+		o.leavesOnly = true
+	}
+	if !flags.Changed("concurrency") && cfg.Concurrency > 0 {
+		o.build.Concurrency = cfg.Concurrency
+	}
+	if !flags.Changed("timeout") && cfg.Timeout != nil {
+		o.build.Timeout = *cfg.Timeout
+	}
+	if !flags.Changed("build-flags") && cfg.BuildFlags != nil {
+		o.build.BuildFlags = cfg.BuildFlags
+	}
+}
+
+// checksFor returns the checks for the kustomization in the given directory: the
+// plain checks, the named rules of the configuration file and the enabled workload
+// rules, without the ones allowed by the configuration file
+func (o *options) checksFor(dir string) []validate.Check {
+	if o.config == nil {
+		return append(validate.RuleChecks(validate.CheckRules(o.checks)), o.workloadChecks...)
+	}
+	plain := o.config.ChecksFor(dir, o.checks)
+	if o.checksFromFlags {
+		plain = o.config.AllowedChecksFor(dir, o.checks)
+	}
+	checks := validate.RuleChecks(validate.CheckRules(plain))
+	for _, rule := range o.config.RulesFor(dir) {
+		checks = append(checks, o.rules[rule.ID])
+	}
+	for _, check := range o.workloadChecks {
+		if !o.config.Allows(dir, check.Name()) {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// validatorOptions returns the options of the validator running the checks of the options.
+// The baseline and the reporters depend on the command and are not included.
+func (o *options) validatorOptions() []validator.Option {
+	opts := []validator.Option{
+		validator.WithPaths(o.path),
+		validator.WithChecksFor(o.checksFor),
+		validator.WithConcurrency(o.build.Concurrency),
+		validator.WithTimeout(o.build.Timeout),
+		validator.WithBuildFlags(o.build.BuildFlags...),
+		validator.WithBuilder(o.build.Builder),
+		validator.WithFilter(o.build.Skip, o.build.Select),
+		validator.WithLeavesOnly(o.leavesOnly),
+		validator.WithChangedSince(o.changedSince),
+	}
+	if o.schema != nil {
+		opts = append(opts, validator.WithSchema(o.schema, o.unknownKind))
+	}
+	if o.admission != nil {
+		opts = append(opts, validator.WithAdmissionPolicies(o.admission))
+	}
+	return opts
+}
@@ -1,101 +1,156 @@
 package commands
 
 import (
-	"context"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
-	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/config"
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
 	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"github.com/redhat-consulting-services/kustomize-validator/validator"
 	"github.com/spf13/cobra"
 )
 
-var (
-	// checkArbitrary is a slice of strings to check for arbitrary validation in the rendered kustomize output
-	// It is set via command line flag
-	checkArbitrary *[]string = &[]string{}
+const (
+	outputText  = "text"
+	outputTable = "table"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+	outputJUnit = "junit"
 )
 
+// reportWriters maps the structured output formats to their writers
+var reportWriters = map[string]func(io.Writer, *report.Report) error{
+	outputJSON:  report.WriteJSON,
+	outputSARIF: report.WriteSARIF,
+	outputJUnit: report.WriteJUnit,
+}
+
 var RootCmd = &cobra.Command{
 	Use:  "kustomize-validator",
 	Long: "A tool to validate Kustomization files",
-	Run: func(cmd *cobra.Command, args []string) {
+	// the path is the only argument, it must not be mistaken for a subcommand
+	Args: cobra.ArbitraryArgs,
+	// errors are printed by main, which also maps them to the exit code
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			fmt.Println("No arguments provided")
-			return
+			return internalError("no arguments provided")
 		}
 
-		fmt.Println("Validating Kustomization files", args[0])
-		isVerbose := cmd.Flag("verbose").Value.String() == "true"
-		isErrorOnly := cmd.Flag("error-only").Value.String() == "true"
-		isTable := cmd.Flag("table").Value.String() == "true"
+		opts, err := resolveOptions(cmd, args[0])
+		if err != nil {
+			return err
+		}
 
-		var tableRows [][]string
-		cwd, _ := os.Getwd()
+		output := opts.output
+		writeReport, isStructured := reportWriters[output]
+		if !isStructured && output != outputText && output != outputTable {
+			return internalError("unsupported output format %q", output)
+		}
+		failOn := opts.failOn
+		if failOn != failOnError && failOn != failOnWarning && failOn != failOnNone {
+			return internalError("unsupported --fail-on threshold %q", failOn)
+		}
+		isTable := output == outputTable
 
-		if isTable {
-			tableRows = append(tableRows, []string{"Relative path", "ApiVersion", "Kind", "Name", "Namespace", "Validation Error"})
+		var baseline *report.Baseline
+		if opts.baselineFile != "" {
+			baseline, err = report.LoadBaseline(opts.baselineFile)
+			if err != nil {
+				return internalError("failed to load baseline: %w", err)
+			}
 		}
 
-		msgChan := validate.KustomizeBuild(args[0])
-		ctx, cf := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cf()
-
-		totalCounter := 0
-		successCounter := 0
-		failureCounter := 0
-
-	BREAK:
-		for {
-			select {
-			case <-ctx.Done():
-				break BREAK
-			case msg, ok := <-msgChan:
-				if !ok {
-					break BREAK
-				}
-				totalCounter++
-				isError := false
-				if msg.Err != nil {
-					isError = true
-				}
+		if !isStructured {
+			fmt.Println("Validating Kustomization files", args[0])
+		}
 
-				// all rendered resources from kustomize output
-				resources := k8s.ParseKustomizeOutput(msg.Stdout, msg.Path, cwd)
+		reporter := writeText(opts)
+		switch {
+		case isStructured:
+			reporter = validator.ReportWriter(os.Stdout, writeReport)
+		case isTable:
+			reporter = writeTable(opts)
+		}
+		v := validator.New(append(opts.validatorOptions(),
+			validator.WithBaseline(baseline, opts.baselineFile),
+			validator.WithReporters(validator.ReporterFunc(printWarnings), reporter),
+		)...)
+		rprt, err := v.Run(cmd.Context())
+		if err != nil {
+			return internalError("%w", err)
+		}
+		return outcome(rprt.Report, rprt.ToolErrors(), failOn)
+	},
+}
 
-				// if no error, validate content
-				rsrcs := validate.ValidateContent(resources, *checkArbitrary)
-				msg.Err = rsrcs.Error()
-				if msg.Err != nil {
-					isError = true
-				}
+// printWarnings prints the warnings of the validation run to stderr
+func printWarnings(r *validator.Report) error {
+	for _, warning := range r.Warnings {
+		fmt.Fprint(os.Stderr, validate.Warningf("%s", warning))
+	}
+	return nil
+}
 
-				if isTable {
-					for _, resource := range resources {
-						tableRows = append(tableRows, []string{
-							resource.SourcePath,
-							resource.ApiVersion,
-							resource.Kind,
-							resource.Name,
-							resource.Namespace,
-							rsrcs.Find(resource.ApiVersion, resource.Kind, resource.Namespace, resource.Name).Error(),
-						})
+// writeText returns the reporter printing the build result and the findings of
+// every kustomization followed by the summary
+func writeText(opts *options) validator.Reporter {
+	return validator.ReporterFunc(func(r *validator.Report) error {
+		for _, result := range r.Results {
+			msg := result.Carrier
+			if msg.Err == nil {
+				msg.Err = result.Findings.Error()
+			}
+			fmt.Print(msg.Msg(opts.errorOnly, opts.verbose))
+			if !opts.errorOnly {
+				// findings below error severity are not part of the build error
+				for _, rsc := range result.Findings {
+					if !rsc.Severity.AtLeast(validate.SeverityError) {
+						fmt.Print(rsc.Msg())
 					}
-				} else {
-					fmt.Print(msg.Msg(isErrorOnly, isVerbose))
 				}
-
-				if isError {
-					failureCounter++
-				} else {
-					successCounter++
+			}
+			if opts.verbose {
+				for _, rsc := range result.Suppressed {
+					fmt.Print(validate.Infof("suppressed by the %s annotation: %s", validate.IgnoreAnnotation, rsc.Error()))
+				}
+				for _, rsc := range result.Baselined {
+					fmt.Print(validate.Infof("recorded in the baseline: %s", rsc.Error()))
 				}
 			}
 		}
+		printSummary(r.Report, opts)
+		return nil
+	})
+}
+
+// writeTable returns the reporter printing the rendered resources of all kustomizations
+// with their findings as table followed by the summary
+func writeTable(opts *options) validator.Reporter {
+	return validator.ReporterFunc(func(r *validator.Report) error {
+		tableRows := [][]string{{"Relative path", "ApiVersion", "Kind", "Name", "Namespace", "Validation Error"}}
+		for _, result := range r.Results {
+			for _, resource := range result.Resources {
+				tableRows = append(tableRows, []string{
+					resource.SourcePath,
+					resource.ApiVersion,
+					resource.Kind,
+					resource.Name,
+					resource.Namespace,
+					findingsColumn(result.Findings.Find(resource.ApiVersion, resource.Kind, resource.Namespace, resource.Name)),
+				})
+			}
+		}
 
-		if isTable && len(tableRows) > 1 {
+		if len(tableRows) > 1 {
 			table := tablewriter.NewTable(os.Stdout)
 
 			// Convert header to []any
@@ -116,18 +171,86 @@ var RootCmd = &cobra.Command{
 			}
 			table.Bulk(data)
 
-			table.Render()
+			if err := table.Render(); err != nil {
+				return err
+			}
 		}
-		fmt.Println("Total: ", validate.ColorF(validate.ColorBlue, "%d", totalCounter))
-		fmt.Println("Success: ", validate.ColorF(validate.ColorGreen, "%d", successCounter))
-		fmt.Println("Error: ", validate.ColorF(validate.ColorRed, "%d", failureCounter))
-		fmt.Println("Failed in %: ", validate.ColorF(validate.ColorRed, "%.2f%%", float64(failureCounter)/float64(totalCounter)*100))
-	},
+		printSummary(r.Report, opts)
+		return nil
+	})
+}
+
+// printSummary prints the counters of the report and the failed kustomizations
+func printSummary(rprt *report.Report, opts *options) {
+	summary := rprt.Summary
+	fmt.Println("Total: ", validate.ColorF(validate.ColorBlue, "%d", summary.Total))
+	fmt.Println("Success: ", validate.ColorF(validate.ColorGreen, "%d", summary.Success))
+	fmt.Println("Error: ", validate.ColorF(validate.ColorRed, "%d", summary.Error))
+	fmt.Println("Timeout: ", validate.ColorF(validate.ColorRed, "%d", summary.Timeout))
+	fmt.Println("Findings: ", validate.ColorF(validate.ColorRed, "%d", summary.Findings))
+	fmt.Println("Suppressed: ", validate.ColorF(validate.ColorBlue, "%d", summary.Suppressed))
+	fmt.Println("Skipped: ", validate.ColorF(validate.ColorBlue, "%d", summary.Skipped))
+	if opts.verbose {
+		for _, dir := range rprt.Skipped {
+			fmt.Print(validate.Infof("skipped kustomization %s", dir))
+		}
+	}
+	if rprt.Baseline != "" {
+		fmt.Println("Baselined: ", validate.ColorF(validate.ColorBlue, "%d", summary.Baselined))
+		fmt.Println("Stale baseline entries: ", validate.ColorF(validate.ColorYellow, "%d", len(rprt.StaleBaseline)))
+		for _, entry := range rprt.StaleBaseline {
+			fmt.Print(validate.Warningf("stale baseline entry, finding(s) no longer found: %s", entry))
+		}
+	}
+	for _, k := range rprt.Kustomizations {
+		if k.Status != report.StatusSuccess {
+			fmt.Println("Failed: ", k.Path, validate.ColorF(validate.ColorRed, "%s", k.Status), k.Relationship())
+		}
+	}
+	// all kustomizations may be skipped, e.g., by --changed-since or --include
+	failed := 0.0
+	if summary.Total > 0 {
+		failed = float64(summary.Error+summary.Timeout) / float64(summary.Total) * 100
+	}
+	fmt.Println("Failed in %: ", validate.ColorF(validate.ColorRed, "%.2f%%", failed))
+}
+
+// findingsColumn formats the findings of a resource for the table view, one per
+// line prefixed with their severity and followed by their remediation
+func findingsColumn(findings validate.Resources) string {
+	msgs := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		msg := fmt.Sprintf("%s: %s", finding.Severity, finding.Error())
+		if finding.Remediation != "" {
+			msg += "\n  remediation: " + finding.Remediation
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "\n")
 }
 
 func init() {
 	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	RootCmd.PersistentFlags().BoolP("error-only", "e", false, "whether we should only log errors")
-	RootCmd.PersistentFlags().BoolP("table", "t", false, "output resources in table format")
-	checkArbitrary = RootCmd.PersistentFlags().StringSliceP("check", "c", []string{"PATCH_ME", "patch_me"}, "check for arbitrary validation in rendered kustomize output.\nUse glob:pattern for glob matching, e.g., glob:PAT*_ME to match PAT123_ME\nor use the regex match pattern regex:app-.* to match app-123.\nIf no prefix is provided, literal substring matching is used (default).")
+	RootCmd.PersistentFlags().BoolP("table", "t", false, "output resources in table format, shorthand for --output table")
+	RootCmd.PersistentFlags().StringP("output", "o", outputText, "output format, one of: text, table, json, sarif, junit")
+	RootCmd.PersistentFlags().String("fail-on", failOnError, "lowest severity of content findings failing the run, one of: error, warning, none")
+	RootCmd.PersistentFlags().Int("concurrency", runtime.NumCPU(), "maximum number of kustomize builds running in parallel")
+	RootCmd.PersistentFlags().Duration("timeout", 2*time.Minute, "timeout of a single kustomize build, 0 disables the timeout")
+	RootCmd.PersistentFlags().Bool("validate-schema", false, "validate rendered resources against the bundled Kubernetes OpenAPI schemas")
+	RootCmd.PersistentFlags().String("kubernetes-version", schema.LatestKubernetesVersion(), "Kubernetes version of the schemas used by --validate-schema, one of: "+strings.Join(schema.KubernetesVersions(), ", "))
+	RootCmd.PersistentFlags().StringSlice("crd-dir", nil, "directories with CustomResourceDefinitions used by --validate-schema to validate custom resources")
+	RootCmd.PersistentFlags().String("unknown-kind-severity", string(validate.SeverityWarning), "severity of resources without schema when using --validate-schema, one of: info, warning, error, "+unknownKindNone)
+	RootCmd.PersistentFlags().Bool("validate-policies", false, "evaluate the ValidatingAdmissionPolicies rendered by any kustomization or loaded from --policy-dir\nagainst the rendered resources, as if the resources were created")
+	RootCmd.PersistentFlags().StringSlice("policy-dir", nil, "directories with ValidatingAdmissionPolicies, their bindings and params used by --validate-policies")
+	RootCmd.PersistentFlags().StringSlice("rules", nil, "built-in workload rules applied to Deployments, StatefulSets, DaemonSets, Jobs and CronJobs, all or any of:\n"+strings.Join(validate.WorkloadRuleIDs(), ", "))
+	RootCmd.PersistentFlags().String("baseline", "", "baseline file of known findings, only findings not recorded in it fail the run")
+	RootCmd.PersistentFlags().String("config", "", "path to the configuration file, by default "+config.FileName+" is searched for in the validated path and its parents")
+	RootCmd.PersistentFlags().StringSlice("exclude", nil, "glob patterns of directories relative to the validated path that are not searched for kustomizations, e.g., **/charts.\nDirectories listed in "+config.IgnoreFileName+" files are excluded as well")
+	RootCmd.PersistentFlags().StringSlice("include", nil, "glob patterns of directories relative to the validated path whose kustomizations are validated, e.g., overlays/*.\nBy default all kustomizations are validated")
+	RootCmd.PersistentFlags().Bool("leaves-only", false, "apply the content and schema checks only to the kustomizations no other kustomization references,\nreferenced bases and components are only checked for buildability")
+	RootCmd.PersistentFlags().String("changed-since", "", "validate only the kustomizations affected by the files changed in the git repository since the reference, e.g., origin/main.\nUnaffected kustomizations are counted as skipped")
+	RootCmd.PersistentFlags().String("engine", validate.EngineExec, "engine building the kustomizations, one of: exec (kustomize binary from PATH), embedded (built-in kustomize)")
+	RootCmd.PersistentFlags().StringSlice("build-flags", validate.DefaultBuildFlags, "flags passed to kustomize build")
+	RootCmd.PersistentFlags().StringSliceP("check", "c", []string{"PATCH_ME", "patch_me"}, "check for arbitrary validation in rendered kustomize output.\nUse glob:pattern for glob matching, e.g., glob:PAT*_ME to match PAT123_ME\nor use the regex match pattern regex:app-.* to match app-123.\nUse path:<field path>=<pattern> to match the values of the fields at a path only,\ne.g., path:spec.template.spec.containers[*].image=regex::latest$.\nIf no prefix is provided, literal substring matching is used (default).")
 }
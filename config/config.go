@@ -0,0 +1,384 @@
+// Package config provides the project configuration file of the validator.
+//
+// The configuration file is discovered by walking up from the validated path
+// and declares the defaults of a repository, so CI pipelines do not have to
+// repeat long command lines. Command line flags override the file.
+//
+// Example:
+//
+//	checks:
+//	  - PATCH_ME
+//	  - regex:\bTODO\b
+//	rules:
+//	  - id: no-latest-tag
+//	    description: Images must be pinned to a version
+//	    severity: warning
+//	    remediation: Use an immutable image tag or digest
+//	    check: path:spec.template.spec.containers[*].image=regex::latest$
+//	  - id: prod-replicas
+//	    description: Production workloads must run at least two replicas
+//	    expression: "!object.metadata.namespace.endsWith('-prod') || object.spec.replicas >= 2"
+//	    message: "{{ .metadata.name }} runs {{ .spec.replicas }} replica(s)"
+//	    fieldPath: spec.replicas
+//	    match:
+//	      kinds: [Deployment, StatefulSet]
+//	      labelSelector:
+//	        matchLabels:
+//	          tier: backend
+//	workloadRules:
+//	  - resource-limits
+//	  - privileged
+//	exclude:
+//	  - vendor
+//	  - "**/charts"
+//	leavesOnly: true
+//	output: sarif
+//	concurrency: 4
+//	timeout: 5m
+//	validateSchema: true
+//	kubernetesVersion: "1.33"
+//	crdDirs:
+//	  - crds
+//	unknownKindSeverity: error
+//	validatePolicies: true
+//	policyDirs:
+//	  - policies
+//	baseline: .kustomize-validator-baseline.yaml
+//	engine: embedded
+//	buildFlags:
+//	  - --enable-helm
+//	overrides:
+//	  - path: overlays/dev
+//	    allow:
+//	      - regex:\bTODO\b
+//	      - no-latest-tag
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project configuration file
+const FileName = ".kustomize-validator.yaml"
+
+// Config is the project configuration of the validator
+type Config struct {
+	// Checks are the content check patterns, see the --check flag
+	Checks []string `yaml:"checks"`
+	// Rules are named content checks with severity, description and remediation
+	Rules []Rule `yaml:"rules"`
+	// WorkloadRules are the IDs of the enabled built-in workload rules, see the --rules flag
+	WorkloadRules []string `yaml:"workloadRules"`
+	// Exclude are glob patterns of directories excluded from discovery, relative to the configuration file
+	Exclude []string `yaml:"exclude"`
+	// LeavesOnly applies the checks only to kustomizations no other kustomization references, see the --leaves-only flag
+	LeavesOnly bool `yaml:"leavesOnly"`
+	// Output is the output format, see the --output flag
+	Output string `yaml:"output"`
+	// FailOn is the lowest severity of content findings failing the run, see the --fail-on flag
+	FailOn string `yaml:"failOn"`
+	// Concurrency is the maximum number of kustomize builds running in parallel
+	Concurrency int `yaml:"concurrency"`
+	// Timeout is the timeout of a single kustomize build, 0 disables it and nil keeps the default
+	Timeout *time.Duration `yaml:"timeout"`
+	// ValidateSchema enables the validation of rendered resources against the bundled Kubernetes schemas
+	ValidateSchema bool `yaml:"validateSchema"`
+	// KubernetesVersion selects the bundled Kubernetes schemas, see the --kubernetes-version flag
+	KubernetesVersion string `yaml:"kubernetesVersion"`
+	// CRDDirectories are directories with CustomResourceDefinitions, relative to the configuration file
+	CRDDirectories []string `yaml:"crdDirs"`
+	// UnknownKindSeverity is the severity of resources without schema, see the --unknown-kind-severity flag
+	UnknownKindSeverity string `yaml:"unknownKindSeverity"`
+	// ValidatePolicies enables the offline evaluation of ValidatingAdmissionPolicies against rendered resources
+	ValidatePolicies bool `yaml:"validatePolicies"`
+	// PolicyDirectories are directories with ValidatingAdmissionPolicies, their bindings and params,
+	// relative to the configuration file
+	PolicyDirectories []string `yaml:"policyDirs"`
+	// Baseline is the baseline file of known findings, relative to the configuration file
+	Baseline string `yaml:"baseline"`
+	// Engine builds the kustomizations, see the --engine flag
+	Engine string `yaml:"engine"`
+	// BuildFlags are passed to kustomize build instead of the default flags
+	BuildFlags []string `yaml:"buildFlags"`
+	// Overrides adjust the checks for kustomizations in specific directories
+	Overrides []Override `yaml:"overrides"`
+
+	// dir is the directory of the configuration file, all paths are relative to it
+	dir string
+}
+
+// Override adjusts the checks for all kustomizations within a directory
+type Override struct {
+	// Path of the directory relative to the configuration file, glob patterns are supported
+	Path string `yaml:"path"`
+	// Checks replace the checks for kustomizations within Path
+	Checks []string `yaml:"checks"`
+	// Allow removes checks and rules, by their ID, for kustomizations within Path
+	Allow []string `yaml:"allow"`
+}
+
+// Rule is a named content check
+type Rule struct {
+	// ID identifies the rule in findings and overrides
+	ID string `yaml:"id"`
+	// Description explains what the rule detects
+	Description string `yaml:"description"`
+	// Severity of the findings of the rule, one of: info, warning, error (default)
+	Severity string `yaml:"severity"`
+	// Remediation describes how to fix the findings of the rule
+	Remediation string `yaml:"remediation"`
+	// Check is the check pattern of the rule, see the --check flag
+	Check string `yaml:"check"`
+	// Expression is a CEL expression the matching resources must satisfy, instead of a check.
+	// The resource is bound to the variable object.
+	Expression string `yaml:"expression"`
+	// Message of the findings of the expression, a text/template rendered with the fields of the resource
+	Message string `yaml:"message"`
+	// FieldPath is the field the findings of the expression are reported at, e.g., spec.replicas
+	FieldPath string `yaml:"fieldPath"`
+	// Match selects the resources the expression applies to, all resources if nil
+	Match *Match `yaml:"match"`
+}
+
+// Match selects resources by their kind and labels
+type Match struct {
+	// Kinds are the kinds of the selected resources, matched literally, all kinds if empty
+	Kinds []string `yaml:"kinds"`
+	// LabelSelector selects resources by their labels
+	LabelSelector *k8s.LabelSelector `yaml:"labelSelector"`
+}
+
+// Discover walks up from the given path and returns the path of the first
+// configuration file found. It returns an empty string if there is none.
+func Discover(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		file := filepath.Join(dir, FileName)
+		_, err := os.Stat(file)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the configuration file at the given path
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, o := range cfg.Overrides {
+		if o.Path == "" {
+			return nil, fmt.Errorf("failed to parse %s: override without path", path)
+		}
+	}
+
+	cfg.dir, err = filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// CRDDirs returns the CustomResourceDefinition directories resolved against the directory of the configuration file
+func (c *Config) CRDDirs() []string {
+	return c.resolve(c.CRDDirectories)
+}
+
+// PolicyDirs returns the ValidatingAdmissionPolicy directories resolved against the directory of the configuration file
+func (c *Config) PolicyDirs() []string {
+	return c.resolve(c.PolicyDirectories)
+}
+
+// resolve resolves the paths against the directory of the configuration file
+func (c *Config) resolve(paths []string) []string {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, path)
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved
+}
+
+// BaselineFile returns the baseline file resolved against the directory of the configuration file,
+// or an empty string if none is configured
+func (c *Config) BaselineFile() string {
+	if c.Baseline == "" || filepath.IsAbs(c.Baseline) {
+		return c.Baseline
+	}
+	return filepath.Join(c.dir, c.Baseline)
+}
+
+// ChecksFor returns the checks for the kustomization in the given directory. The
+// overrides matching the directory are applied in order on top of the given checks.
+func (c *Config) ChecksFor(dir string, checks []string) []string {
+	return c.checksFor(dir, checks, true)
+}
+
+// AllowedChecksFor returns the given checks without the ones allowed by the overrides
+// matching the directory. Unlike ChecksFor, the checks of the overrides do not replace
+// them, as for the checks set on the command line.
+func (c *Config) AllowedChecksFor(dir string, checks []string) []string {
+	return c.checksFor(dir, checks, false)
+}
+
+// checksFor applies the overrides matching the directory to the checks, their checks
+// replace the given ones only if replace is set
+func (c *Config) checksFor(dir string, checks []string, replace bool) []string {
+	rel, ok := c.relative(dir)
+	if !ok {
+		return checks
+	}
+
+	for _, o := range c.Overrides {
+		if !matchPath(o.Path, rel) {
+			continue
+		}
+		if replace && len(o.Checks) > 0 {
+			checks = o.Checks
+		}
+		if len(o.Allow) > 0 {
+			var remaining []string
+			for _, check := range checks {
+				if !contains(o.Allow, check) {
+					remaining = append(remaining, check)
+				}
+			}
+			checks = remaining
+		}
+	}
+	return checks
+}
+
+// RulesFor returns the rules for the kustomization in the given directory, without
+// the rules allowed by the overrides matching the directory
+func (c *Config) RulesFor(dir string) []Rule {
+	rel, ok := c.relative(dir)
+	if !ok {
+		return c.Rules
+	}
+
+	rules := c.Rules
+	for _, o := range c.Overrides {
+		if !matchPath(o.Path, rel) || len(o.Allow) == 0 {
+			continue
+		}
+		var remaining []Rule
+		for _, rule := range rules {
+			if !contains(o.Allow, rule.ID) {
+				remaining = append(remaining, rule)
+			}
+		}
+		rules = remaining
+	}
+	return rules
+}
+
+// Allows reports whether an override matching the given directory allows the check or rule with the given ID
+func (c *Config) Allows(dir, id string) bool {
+	rel, ok := c.relative(dir)
+	if !ok {
+		return false
+	}
+	for _, o := range c.Overrides {
+		if matchPath(o.Path, rel) && contains(o.Allow, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excluded reports whether the given directory is excluded from discovery
+func (c *Config) Excluded(dir string) bool {
+	rel, ok := c.relative(dir)
+	if !ok {
+		return false
+	}
+	for _, pattern := range c.Exclude {
+		if matchPath(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// relative returns the given path relative to the directory of the configuration
+// file and whether the path is located within that directory
+func (c *Config) relative(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(c.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// matchPath reports whether the slash separated path or one of its parent
+// directories matches the pattern. A leading "**/" matches any parent directory.
+func matchPath(pattern, path string) bool {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+	anyParent := strings.HasPrefix(pattern, "**/")
+	pattern = strings.TrimPrefix(pattern, "**/")
+
+	segments := strings.Split(path, "/")
+	for start := range segments {
+		if start > 0 && !anyParent {
+			break
+		}
+		for end := start + 1; end <= len(segments); end++ {
+			if ok, _ := pathpkg.Match(pattern, strings.Join(segments[start:end], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var configExample = `checks:
+  - PATCH_ME
+  - TODO
+rules:
+  - id: no-latest-tag
+    description: Images must be pinned to a version
+    severity: warning
+    check: path:spec.template.spec.containers[*].image=regex::latest$
+  - id: no-debug
+    description: Debug logging must be disabled
+    check: "debug: true"
+exclude:
+  - vendor
+  - "**/charts"
+concurrency: 4
+timeout: 5m
+crdDirs:
+  - crds
+  - /opt/crds
+validatePolicies: true
+policyDirs:
+  - policies
+overrides:
+  - path: overlays/dev
+    allow:
+      - TODO
+      - no-debug
+  - path: overlays/*/legacy
+    checks:
+      - FIXME
+`
+
+func writeConfig(t *testing.T, content string) (string, *Config) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "overlays", "dev"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, FileName)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return root, cfg
+}
+
+func TestDiscover(t *testing.T) {
+	root, _ := writeConfig(t, configExample)
+
+	got, err := Discover(filepath.Join(root, "overlays", "dev"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != filepath.Join(root, FileName) {
+		t.Errorf("expected %s, got: %s", filepath.Join(root, FileName), got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, cfg := writeConfig(t, configExample)
+	if cfg.Concurrency != 4 || cfg.Timeout == nil || *cfg.Timeout != 5*time.Minute || len(cfg.Overrides) != 2 {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+
+	file := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(file, []byte("chekcs: [PATCH_ME]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
+
+func TestConfig_CRDDirs(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	want := []string{filepath.Join(root, "crds"), "/opt/crds"}
+	if got := cfg.CRDDirs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got: %v", want, got)
+	}
+}
+
+func TestConfig_PolicyDirs(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	want := []string{filepath.Join(root, "policies")}
+	if got := cfg.PolicyDirs(); !cfg.ValidatePolicies || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got: %v", want, got)
+	}
+}
+
+func TestConfig_ChecksFor(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{name: "no override", dir: "overlays/prod", want: []string{"PATCH_ME", "TODO"}},
+		{name: "allow", dir: "overlays/dev", want: []string{"PATCH_ME"}},
+		{name: "allow in subdirectory", dir: "overlays/dev/app", want: []string{"PATCH_ME"}},
+		{name: "replace with glob", dir: "overlays/prod/legacy", want: []string{"FIXME"}},
+		{name: "outside of configuration", dir: "..", want: []string{"PATCH_ME", "TODO"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.ChecksFor(filepath.Join(root, tt.dir), cfg.Checks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfig_AllowedChecksFor(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	checks := []string{"TODO", "XXX"}
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{name: "allow", dir: "overlays/dev", want: []string{"XXX"}},
+		{name: "checks of the override are ignored", dir: "overlays/prod/legacy", want: []string{"TODO", "XXX"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.AllowedChecksFor(filepath.Join(root, tt.dir), checks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	_, disabled := writeConfig(t, "timeout: 0s\n")
+	if disabled.Timeout == nil || *disabled.Timeout != 0 {
+		t.Errorf("expected disabled timeout, got: %v", disabled.Timeout)
+	}
+}
+
+func TestConfig_RulesFor(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{name: "no override", dir: "overlays/prod", want: []string{"no-latest-tag", "no-debug"}},
+		{name: "allow", dir: "overlays/dev/app", want: []string{"no-latest-tag"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, rule := range cfg.RulesFor(filepath.Join(root, tt.dir)) {
+				got = append(got, rule.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfig_Allows(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	tests := []struct {
+		dir  string
+		id   string
+		want bool
+	}{
+		{dir: "overlays/dev/app", id: "no-debug", want: true},
+		{dir: "overlays/dev", id: "privileged", want: false},
+		{dir: "overlays/prod", id: "no-debug", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir+" "+tt.id, func(t *testing.T) {
+			if got := cfg.Allows(filepath.Join(root, tt.dir), tt.id); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfig_Excluded(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{dir: "vendor", want: true},
+		{dir: "apps/vendor", want: false},
+		{dir: "apps/nginx/charts", want: true},
+		{dir: "overlays/dev", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := cfg.Excluded(filepath.Join(root, tt.dir)); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
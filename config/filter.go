@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the ignore files excluding directories from discovery.
+// The files use the syntax of .gitignore files and apply to the directory they are
+// located in and all of its subdirectories.
+const IgnoreFileName = ".kustomize-validator-ignore"
+
+// Filter decides which directories below the validated path are searched for
+// kustomizations and which of the found kustomizations are built
+type Filter struct {
+	// Exclude are glob patterns of directories not descended into, relative to the validated path
+	Exclude []string
+	// Include are glob patterns of the directories whose kustomizations are built,
+	// relative to the validated path. If empty, all kustomizations are built.
+	Include []string
+
+	// root is the validated path
+	root string
+	// config is the project configuration file, nil if there is none
+	config *Config
+	// ignores caches the patterns of the ignore file per directory
+	ignores map[string][]ignorePattern
+}
+
+// ignorePattern is a single pattern of an ignore file
+type ignorePattern struct {
+	// pattern is the glob pattern without negation, anchor and trailing slash
+	pattern string
+	// negate re-includes directories excluded by previous patterns
+	negate bool
+	// anchored patterns match the path relative to the ignore file, others the directory name
+	anchored bool
+}
+
+// NewFilter creates the filter for the validated path. The exclude patterns of
+// the configuration file are applied in addition to the given patterns.
+func NewFilter(root string, exclude, include []string, cfg *Config) (*Filter, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{
+		Exclude: exclude,
+		Include: include,
+		root:    abs,
+		config:  cfg,
+		ignores: map[string][]ignorePattern{},
+	}, nil
+}
+
+// Skip reports whether the directory is excluded by the --exclude patterns, the
+// configuration file or an ignore file. Skipped directories are not descended into.
+func (f *Filter) Skip(dir string) bool {
+	if f.config != nil && f.config.Excluded(dir) {
+		return true
+	}
+	rel, ok := f.relative(dir)
+	if !ok || rel == "." {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if matchPath(pattern, rel) {
+			return true
+		}
+	}
+	return f.ignored(rel)
+}
+
+// Select reports whether the kustomization in the directory matches the --include patterns
+func (f *Filter) Select(dir string) bool {
+	if len(f.Include) == 0 {
+		return true
+	}
+	rel, ok := f.relative(dir)
+	if !ok {
+		return false
+	}
+	for _, pattern := range f.Include {
+		if matchPath(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether the slash separated path relative to the validated path
+// is excluded by the ignore files of the validated path and its subdirectories.
+// Later patterns take precedence over earlier ones and deeper files over the
+// files of their parent directories.
+func (f *Filter) ignored(rel string) bool {
+	segments := strings.Split(rel, "/")
+	ignored := false
+	for depth := 0; depth < len(segments); depth++ {
+		dir := filepath.Join(f.root, filepath.FromSlash(strings.Join(segments[:depth], "/")))
+		path := strings.Join(segments[depth:], "/")
+		for _, pattern := range f.ignoreFile(dir) {
+			if pattern.match(path) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// ignoreFile returns the patterns of the ignore file in the directory, loading it on first use
+func (f *Filter) ignoreFile(dir string) []ignorePattern {
+	if patterns, ok := f.ignores[dir]; ok {
+		return patterns
+	}
+	// a missing or unreadable ignore file excludes nothing
+	content, _ := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	patterns := parseIgnoreFile(content)
+	f.ignores[dir] = patterns
+	return patterns
+}
+
+// relative returns the path relative to the validated path and whether it is located within it
+func (f *Filter) relative(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(f.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// parseIgnoreFile parses the content of an ignore file. Blank lines and lines
+// starting with # are ignored.
+func parseIgnoreFile(content []byte) []ignorePattern {
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimSuffix(line, "/")
+		// a slash at the beginning or in the middle anchors the pattern to the directory of the file
+		pattern.anchored = strings.Contains(line, "/")
+		pattern.pattern = strings.TrimPrefix(line, "/")
+		if pattern.pattern == "" {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// match reports whether the pattern matches the slash separated directory path
+// relative to the directory of the ignore file
+func (p ignorePattern) match(path string) bool {
+	if !p.anchored {
+		return matchSegments([]string{p.pattern}, []string{pathpkg.Base(path)})
+	}
+	return matchSegments(strings.Split(p.pattern, "/"), strings.Split(path, "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments, where
+// a ** segment matches any number of path segments
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := pathpkg.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilter_Skip(t *testing.T) {
+	root, cfg := writeConfig(t, configExample)
+	ignoreFiles := map[string]string{
+		IgnoreFileName:                        "# fixtures of the tests\ntest/fixtures/\n.git\nhelm/*/templates\n/tmp\ngenerated\n",
+		filepath.Join("apps", IgnoreFileName): "legacy\n!generated\n",
+	}
+	for name, content := range ignoreFiles {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filter, err := NewFilter(root, []string{"**/examples"}, nil, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{dir: ".", want: false},
+		{dir: "vendor", want: true},
+		{dir: "apps/nginx/examples", want: true},
+		{dir: "test/fixtures", want: true},
+		{dir: "apps/test/fixtures", want: false},
+		{dir: ".git", want: true},
+		{dir: "apps/.git", want: true},
+		{dir: "helm/nginx/templates", want: true},
+		{dir: "helm/nginx", want: false},
+		{dir: "tmp", want: true},
+		{dir: "apps/tmp", want: false},
+		{dir: "apps/legacy", want: true},
+		{dir: "legacy", want: false},
+		{dir: "generated", want: true},
+		{dir: "apps/generated", want: false},
+		{dir: "overlays/dev", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := filter.Skip(filepath.Join(root, tt.dir)); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilter_Select(t *testing.T) {
+	root := t.TempDir()
+	filter, err := NewFilter(root, nil, []string{"overlays/*", "**/prod"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{dir: "overlays/dev", want: true},
+		{dir: "overlays/dev/eu", want: true},
+		{dir: "apps/nginx/prod", want: true},
+		{dir: "base", want: false},
+		{dir: "../other/overlays/dev", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := filter.Select(filepath.Join(root, tt.dir)); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
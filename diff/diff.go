@@ -0,0 +1,190 @@
+// Package diff compares the rendered resources of kustomizations at two revisions.
+//
+// Resources are matched by their apiVersion, kind, namespace and name. Resources
+// only rendered at one revision are added or removed, matched resources with a
+// different content are modified and carry a unified diff of their YAML.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// Change is the kind of difference of a resource or kustomization between the revisions
+type Change string
+
+const (
+	ChangeAdded     Change = "added"
+	ChangeRemoved   Change = "removed"
+	ChangeModified  Change = "modified"
+	ChangeUnchanged Change = "unchanged"
+)
+
+// contextLines is the number of unchanged lines around every change of a unified diff
+const contextLines = 3
+
+// Report contains the differences of all compared kustomizations
+type Report struct {
+	// Path the comparison was started from
+	Path string `json:"path"`
+	// From and To are the compared revisions
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Kustomizations []Kustomization `json:"kustomizations"`
+}
+
+// Kustomization contains the differences of the rendered resources of a single kustomization
+type Kustomization struct {
+	// Path of the kustomization relative to the compared path
+	Path   string `json:"path"`
+	Change Change `json:"change"`
+	// FromError and ToError are the build errors at the revisions, if any
+	FromError string           `json:"fromError,omitempty"`
+	ToError   string           `json:"toError,omitempty"`
+	Resources []ResourceChange `json:"resources"`
+}
+
+// ResourceChange is the difference of a single rendered resource
+type ResourceChange struct {
+	ApiVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Change     Change `json:"change"`
+	// Diff is the unified diff of the YAML of modified resources
+	Diff string `json:"diff,omitempty"`
+}
+
+// Build is the rendered output of a kustomization at one revision
+type Build struct {
+	// Exists is set if the kustomization exists at the revision
+	Exists    bool
+	Err       error
+	Resources []k8s.Resource
+}
+
+// Compare compares the builds of the kustomization at path at both revisions.
+// Resources are sorted by kind, namespace, name and apiVersion.
+func Compare(path, fromLabel, toLabel string, from, to Build) Kustomization {
+	k := Kustomization{Path: path, Resources: []ResourceChange{}}
+	if from.Err != nil {
+		k.FromError = from.Err.Error()
+	}
+	if to.Err != nil {
+		k.ToError = to.Err.Error()
+	}
+
+	fromResources := index(from.Resources)
+	toResources := index(to.Resources)
+	for key, resource := range toResources {
+		old, ok := fromResources[key]
+		switch {
+		case !ok:
+			k.Resources = append(k.Resources, newChange(resource, ChangeAdded, ""))
+		case old.FileContent != resource.FileContent:
+			diff := unified(old.FileContent, resource.FileContent,
+				fromLabel+"/"+path+"/"+key, toLabel+"/"+path+"/"+key)
+			k.Resources = append(k.Resources, newChange(resource, ChangeModified, diff))
+		}
+	}
+	for key, resource := range fromResources {
+		if _, ok := toResources[key]; !ok {
+			k.Resources = append(k.Resources, newChange(resource, ChangeRemoved, ""))
+		}
+	}
+	sort.Slice(k.Resources, func(i, j int) bool {
+		return k.Resources[i].less(k.Resources[j])
+	})
+
+	switch {
+	case !from.Exists:
+		k.Change = ChangeAdded
+	case !to.Exists:
+		k.Change = ChangeRemoved
+	case len(k.Resources) > 0 || k.FromError != k.ToError:
+		k.Change = ChangeModified
+	default:
+		k.Change = ChangeUnchanged
+	}
+	return k
+}
+
+// Changed returns the kustomizations with differences
+func (r *Report) Changed() []Kustomization {
+	var changed []Kustomization
+	for _, k := range r.Kustomizations {
+		if k.Change != ChangeUnchanged {
+			changed = append(changed, k)
+		}
+	}
+	return changed
+}
+
+// Select returns the resource changes of the given kind of change
+func (k Kustomization) Select(change Change) []ResourceChange {
+	var selected []ResourceChange
+	for _, r := range k.Resources {
+		if r.Change == change {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+// String identifies the resource like the findings of the validation
+func (r ResourceChange) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", r.ApiVersion, r.Kind, r.Namespace, r.Name)
+}
+
+func (r ResourceChange) less(o ResourceChange) bool {
+	if r.Kind != o.Kind {
+		return r.Kind < o.Kind
+	}
+	if r.Namespace != o.Namespace {
+		return r.Namespace < o.Namespace
+	}
+	if r.Name != o.Name {
+		return r.Name < o.Name
+	}
+	return r.ApiVersion < o.ApiVersion
+}
+
+func newChange(resource k8s.Resource, change Change, diff string) ResourceChange {
+	return ResourceChange{
+		ApiVersion: resource.ApiVersion,
+		Kind:       resource.Kind,
+		Namespace:  resource.Namespace,
+		Name:       resource.Name,
+		Change:     change,
+		Diff:       diff,
+	}
+}
+
+// index maps the resources by their identity. Later duplicates replace earlier ones,
+// kustomize does not render duplicate resources.
+func index(resources []k8s.Resource) map[string]k8s.Resource {
+	indexed := map[string]k8s.Resource{}
+	for _, resource := range resources {
+		indexed[fmt.Sprintf("%s/%s/%s/%s", resource.ApiVersion, resource.Kind, resource.Namespace, resource.Name)] = resource
+	}
+	return indexed
+}
+
+// unified returns the unified diff of the two documents
+func unified(from, to, fromLabel, toLabel string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimRight(from, "\n") + "\n"),
+		B:        difflib.SplitLines(strings.TrimRight(to, "\n") + "\n"),
+		FromFile: fromLabel,
+		ToFile:   toLabel,
+		Context:  contextLines,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return diff
+}
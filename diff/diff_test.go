@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+func resource(kind, name, content string) k8s.Resource {
+	return k8s.Resource{ApiVersion: "v1", Kind: kind, Namespace: "<none>", Name: name, FileContent: content}
+}
+
+func TestCompare(t *testing.T) {
+	from := Build{Exists: true, Resources: []k8s.Resource{
+		resource("ConfigMap", "settings", "kind: ConfigMap\ndata:\n  level: info\n"),
+		resource("Service", "my-app", "kind: Service\n"),
+		resource("Secret", "legacy", "kind: Secret\n"),
+	}}
+	to := Build{Exists: true, Resources: []k8s.Resource{
+		resource("Service", "my-app", "kind: Service\n"),
+		resource("ConfigMap", "settings", "kind: ConfigMap\ndata:\n  level: debug\n"),
+		resource("ConfigMap", "new", "kind: ConfigMap\n"),
+	}}
+
+	k := Compare("overlays/prod", "main", "HEAD", from, to)
+	if k.Change != ChangeModified {
+		t.Errorf("expected change %s, got: %s", ChangeModified, k.Change)
+	}
+	var got []string
+	for _, r := range k.Resources {
+		got = append(got, string(r.Change)+" "+r.String())
+	}
+	want := "added v1/ConfigMap/<none>/new,modified v1/ConfigMap/<none>/settings,removed v1/Secret/<none>/legacy"
+	if strings.Join(got, ",") != want {
+		t.Errorf("expected %s, got: %s", want, strings.Join(got, ","))
+	}
+	diff := k.Select(ChangeModified)[0].Diff
+	for _, line := range []string{
+		"--- main/overlays/prod/v1/ConfigMap/<none>/settings",
+		"+++ HEAD/overlays/prod/v1/ConfigMap/<none>/settings",
+		"-  level: info",
+		"+  level: debug",
+	} {
+		if !strings.Contains(diff, line+"\n") {
+			t.Errorf("expected line %q in diff:\n%s", line, diff)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to Build
+		want     Change
+	}{
+		{name: "unchanged", from: from, to: from, want: ChangeUnchanged},
+		{name: "added", from: Build{}, to: to, want: ChangeAdded},
+		{name: "removed", from: from, to: Build{}, want: ChangeRemoved},
+		{name: "build broken", from: from, to: Build{Exists: true, Resources: from.Resources, Err: errors.New("exit status 1")}, want: ChangeModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare("base", "main", "HEAD", tt.from, tt.to).Change; got != tt.want {
+				t.Errorf("expected change %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	from := Build{Exists: true, Resources: []k8s.Resource{resource("ConfigMap", "settings", "level: info\n")}}
+	to := Build{Exists: true, Resources: []k8s.Resource{resource("ConfigMap", "settings", "level: debug\n")}}
+	r := &Report{Path: ".", From: "main", To: "HEAD", Kustomizations: []Kustomization{
+		Compare("overlays/prod", "main", "HEAD", from, to),
+		Compare("overlays/dev", "main", "HEAD", from, from),
+	}}
+
+	buf := &bytes.Buffer{}
+	if err := WriteMarkdown(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"**1** of **2** kustomization(s) changed.",
+		"#### `overlays/prod` (modified)",
+		"| modified | `v1/ConfigMap/<none>/settings` |",
+		"<summary>v1/ConfigMap/&lt;none&gt;/settings</summary>",
+		"```diff\n--- main/overlays/prod/v1/ConfigMap/<none>/settings\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "overlays/dev") {
+		t.Errorf("expected unchanged kustomizations to be omitted:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// WriteText writes the changed kustomizations with their added, removed and
+// modified resources followed by the unified diffs of the modified resources
+func WriteText(w io.Writer, r *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Comparing the rendered resources of %s from %s to %s\n", r.Path, r.From, r.To)
+	changed := r.Changed()
+	for _, k := range changed {
+		fmt.Fprintf(&b, "\n== %s (%s)\n", k.Path, k.Change)
+		if k.FromError != "" {
+			fmt.Fprintf(&b, "build failed at %s: %s\n", r.From, k.FromError)
+		}
+		if k.ToError != "" {
+			fmt.Fprintf(&b, "build failed at %s: %s\n", r.To, k.ToError)
+		}
+		for _, change := range []Change{ChangeAdded, ChangeRemoved, ChangeModified} {
+			for _, resource := range k.Select(change) {
+				fmt.Fprintf(&b, "%s: %s\n", change, resource)
+			}
+		}
+		for _, resource := range k.Select(ChangeModified) {
+			b.WriteString("\n")
+			b.WriteString(resource.Diff)
+		}
+	}
+	fmt.Fprintf(&b, "\nChanged: %d of %d kustomization(s)\n", len(changed), len(r.Kustomizations))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the changes as Markdown suitable for a pull request comment.
+// The unified diffs are collapsed, so large changes do not bury the summary.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Rendered diff of `%s` from `%s` to `%s`\n\n", r.Path, r.From, r.To)
+	changed := r.Changed()
+	if len(changed) == 0 {
+		fmt.Fprintf(&b, "No changes in %d kustomization(s).\n", len(r.Kustomizations))
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%d** of **%d** kustomization(s) changed.\n", len(changed), len(r.Kustomizations))
+	for _, k := range changed {
+		fmt.Fprintf(&b, "\n#### `%s` (%s)\n\n", k.Path, k.Change)
+		if k.FromError != "" {
+			fmt.Fprintf(&b, "> **Warning**\n> Build failed at `%s`: %s\n\n", r.From, quote(k.FromError))
+		}
+		if k.ToError != "" {
+			fmt.Fprintf(&b, "> **Warning**\n> Build failed at `%s`: %s\n\n", r.To, quote(k.ToError))
+		}
+		if len(k.Resources) > 0 {
+			b.WriteString("| Change | Resource |\n|---|---|\n")
+			for _, change := range []Change{ChangeAdded, ChangeRemoved, ChangeModified} {
+				for _, resource := range k.Select(change) {
+					fmt.Fprintf(&b, "| %s | `%s` |\n", change, resource)
+				}
+			}
+		}
+		for _, resource := range k.Select(ChangeModified) {
+			fmt.Fprintf(&b, "\n<details>\n<summary>%s</summary>\n\n```diff\n%s```\n\n</details>\n", html.EscapeString(resource.String()), resource.Diff)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as a single indented JSON document
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(r)
+}
+
+// quote continues a multi-line message within a Markdown block quote
+func quote(msg string) string {
+	return strings.ReplaceAll(strings.TrimSpace(msg), "\n", "\n> ")
+}
@@ -0,0 +1,114 @@
+// Package git provides access to the local git repository of the validated path.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TopLevel returns the absolute path of the root directory of the git repository containing dir
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(out)), nil
+}
+
+// ChangedFiles returns the absolute paths of the files changed in the working tree
+// of the repository containing dir since it diverged from the given reference,
+// including uncommitted and untracked files. Deleted and renamed files are
+// returned with their old and new paths.
+func ChangedFiles(ctx context.Context, dir, ref string) ([]string, error) {
+	top, err := TopLevel(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	// compare with the merge base, so changes on the reference since are not included
+	base, err := run(ctx, top, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := run(ctx, top, "diff", "--name-only", "--no-renames", "-z", strings.TrimSpace(base))
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := run(ctx, top, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	return absolute(top, diff, untracked), nil
+}
+
+// DiffFiles returns the absolute paths of the files changed between the two references
+// in the repository containing dir, the paths are located in the working tree
+func DiffFiles(ctx context.Context, dir, from, to string) ([]string, error) {
+	top, err := TopLevel(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := run(ctx, top, "diff", "--name-only", "--no-renames", "-z", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return absolute(top, diff), nil
+}
+
+// AddWorktree checks out the reference in a new temporary worktree of the repository
+// containing dir and returns its path. The worktree must be removed with RemoveWorktree.
+func AddWorktree(ctx context.Context, dir, ref string) (string, error) {
+	tmp, err := os.MkdirTemp("", "kustomize-validator-worktree-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := run(ctx, dir, "worktree", "add", "--detach", "--quiet", tmp, ref); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// RemoveWorktree removes the worktree at path from the repository containing dir
+func RemoveWorktree(ctx context.Context, dir, path string) error {
+	_, err := run(ctx, dir, "worktree", "remove", "--force", path)
+	if err != nil {
+		// the directory is removed even if git failed, the worktree is pruned later
+		os.RemoveAll(path)
+	}
+	return err
+}
+
+// absolute converts the NUL separated file names of the outputs, relative to top,
+// into sorted absolute paths without duplicates
+func absolute(top string, outputs ...string) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, name := range strings.Split(strings.Join(outputs, ""), "\x00") {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, filepath.Join(top, filepath.FromSlash(name)))
+	}
+	sort.Strings(files)
+	return files
+}
+
+// run runs git with the arguments in dir and returns its stdout
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// helpers returns functions running git in dir and writing files relative to dir
+func helpers(t *testing.T, dir string) (func(args ...string), func(name, content string)) {
+	gitCmd := func(args ...string) {
+		t.Helper()
+		if _, err := run(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd("init", "-q", "-b", "main")
+	gitCmd("config", "user.email", "test@example.com")
+	gitCmd("config", "user.name", "test")
+	return gitCmd, write
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	gitCmd, write := helpers(t, dir)
+	write("base/deployment.yaml", "kind: Deployment\n")
+	write("base/service.yaml", "kind: Service\n")
+	write("overlays/dev/kustomization.yaml", "resources: []\n")
+	gitCmd("add", "-A")
+	gitCmd("commit", "-q", "-m", "initial")
+	gitCmd("checkout", "-q", "-b", "feature")
+
+	write("base/deployment.yaml", "kind: Deployment\nmetadata: {}\n")
+	gitCmd("commit", "-q", "-am", "change the deployment")
+	// uncommitted, deleted and untracked files are changed as well
+	write("overlays/dev/kustomization.yaml", "resources:\n  - ../../base\n")
+	if err := os.Remove(filepath.Join(dir, "base", "service.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	write("overlays/prod/kustomization.yaml", "resources: []\n")
+
+	got, err := ChangedFiles(ctx, filepath.Join(dir, "overlays"), "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	top, err := TopLevel(ctx, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		filepath.Join(top, "base", "deployment.yaml"),
+		filepath.Join(top, "base", "service.yaml"),
+		filepath.Join(top, "overlays", "dev", "kustomization.yaml"),
+		filepath.Join(top, "overlays", "prod", "kustomization.yaml"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got: %v", want, got)
+	}
+
+	if _, err := ChangedFiles(ctx, dir, "does-not-exist"); err == nil {
+		t.Errorf("expected error for unknown reference")
+	}
+}
+
+func TestWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	gitCmd, write := helpers(t, dir)
+	write("base/deployment.yaml", "replicas: 1\n")
+	gitCmd("add", "-A")
+	gitCmd("commit", "-q", "-m", "initial")
+	gitCmd("tag", "v1")
+	write("base/deployment.yaml", "replicas: 2\n")
+	write("base/service.yaml", "kind: Service\n")
+	gitCmd("add", "-A")
+	gitCmd("commit", "-q", "-m", "scale")
+
+	worktree, err := AddWorktree(ctx, dir, "v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(worktree, "base", "deployment.yaml"))
+	if err != nil || string(content) != "replicas: 1\n" {
+		t.Errorf("expected the content of v1 in the worktree, got: %q, %v", content, err)
+	}
+	if err := RemoveWorktree(ctx, dir, worktree); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(worktree); !os.IsNotExist(err) {
+		t.Errorf("expected the worktree to be removed, got: %v", err)
+	}
+
+	files, err := DiffFiles(ctx, dir, "v1", "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	top, _ := TopLevel(ctx, dir)
+	want := []string{filepath.Join(top, "base", "deployment.yaml"), filepath.Join(top, "base", "service.yaml")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %v, got: %v", want, files)
+	}
+}
@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Affected returns the directories of the kustomizations affected by changes of
+// the given files, sorted. A file affects the kustomization in the nearest
+// directory containing it, the kustomizations referencing it in any field and
+// all kustomizations referencing an affected kustomization, transitively. It
+// fails if a kustomization file cannot be parsed, as its references are unknown.
+func (g *Graph) Affected(changed []string) ([]string, error) {
+	dirs := map[string]string{}
+	files := map[string][]string{}
+	for _, dir := range g.Dirs() {
+		node := g.Nodes[dir]
+		if node.Err != nil {
+			return nil, fmt.Errorf("kustomization %s cannot be resolved: %w", dir, node.Err)
+		}
+		dirs[canonical(dir)] = dir
+		for _, file := range node.Files {
+			files[canonical(file)] = append(files[canonical(file)], dir)
+		}
+	}
+
+	affected := map[string]bool{}
+	var queue []string
+	add := func(dir string) {
+		if !affected[dir] {
+			affected[dir] = true
+			queue = append(queue, dir)
+		}
+	}
+	for _, file := range changed {
+		file = canonical(file)
+		// the nearest kustomization containing the file
+		for dir := filepath.Dir(file); ; dir = filepath.Dir(dir) {
+			if owner, ok := dirs[dir]; ok {
+				add(owner)
+				break
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		// the kustomizations referencing the file or one of its parent directories
+		for path, referrers := range files {
+			if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+				for _, dir := range referrers {
+					add(dir)
+				}
+			}
+		}
+	}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		for _, referrer := range g.Nodes[dir].ReferencedBy {
+			add(referrer)
+		}
+	}
+
+	var result []string
+	for _, dir := range g.Dirs() {
+		if affected[dir] {
+			result = append(result, dir)
+		}
+	}
+	return result, nil
+}
+
+// canonical returns the absolute path with symbolic links resolved, so paths of
+// the graph can be compared with the paths reported by git
+func canonical(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	// deleted files cannot be resolved, but their parent directory may
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(parent, filepath.Base(abs))
+	}
+	return abs
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraph_Affected(t *testing.T) {
+	g, dir := buildTree(t, map[string]string{
+		"base/kustomization.yaml":           "resources:\n  - deployment.yaml\n",
+		"base/deployment.yaml":              "kind: Deployment\n",
+		"base/docs/README.md":               "# base\n",
+		"monitoring/kustomization.yaml":     "kind: Component\n",
+		"shared/patch.yaml":                 "kind: Deployment\n",
+		"shared/config/app.properties":      "debug=false\n",
+		"overlays/dev/kustomization.yaml":   "resources:\n  - ../../base\ncomponents:\n  - ../../monitoring\n",
+		"overlays/prod/kustomization.yaml":  "resources:\n  - ../../base\npatches:\n  - path: ../../shared/patch.yaml\n",
+		"overlays/test/kustomization.yaml":  "configMapGenerator:\n  - name: app\n    files:\n      - app.properties=../../shared/config/app.properties\n",
+		"overlays/stage/kustomization.yaml": "resources:\n  - ../../base\n  - ../../shared/cm.yaml\n",
+		"standalone/kustomization.yaml":     "resources: []\n",
+	})
+
+	tests := []struct {
+		name    string
+		changed []string
+		want    []string
+	}{
+		{
+			name:    "base resource",
+			changed: []string{dir("base/deployment.yaml")},
+			want:    []string{dir("base"), dir("overlays/dev"), dir("overlays/prod"), dir("overlays/stage")},
+		},
+		{
+			name:    "file not referenced within a kustomization",
+			changed: []string{dir("base/docs/README.md")},
+			want:    []string{dir("base"), dir("overlays/dev"), dir("overlays/prod"), dir("overlays/stage")},
+		},
+		{
+			name:    "component",
+			changed: []string{dir("monitoring/kustomization.yaml")},
+			want:    []string{dir("monitoring"), dir("overlays/dev")},
+		},
+		{
+			name:    "patch outside of the kustomization",
+			changed: []string{dir("shared/patch.yaml")},
+			want:    []string{dir("overlays/prod")},
+		},
+		{
+			name:    "generator source",
+			changed: []string{dir("shared/config/app.properties")},
+			want:    []string{dir("overlays/test")},
+		},
+		{
+			name:    "deleted file",
+			changed: []string{dir("overlays/prod/removed.yaml")},
+			want:    []string{dir("overlays/prod")},
+		},
+		{
+			name:    "deleted file outside of any kustomization",
+			changed: []string{dir("shared/cm.yaml")},
+			want:    []string{dir("overlays/stage")},
+		},
+		{
+			name:    "unrelated file",
+			changed: []string{dir("README.md")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Affected(tt.changed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	broken, _ := buildTree(t, treeExample)
+	if _, err := broken.Affected(nil); err == nil {
+		t.Errorf("expected error for a kustomization that cannot be parsed")
+	}
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// baseDirNames are the directory names conventionally containing bases
+var baseDirNames = []string{"base", "bases"}
+
+// Edge is a reference of the kustomization in the directory From
+type Edge struct {
+	From string
+	Reference
+}
+
+// Cycles returns the groups of kustomizations referencing each other directly
+// or transitively. Every group is sorted, the groups are sorted by their first
+// kustomization.
+func (g *Graph) Cycles() [][]string {
+	// Tarjan's algorithm for strongly connected components
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(dir string)
+	visit = func(dir string) {
+		index[dir] = len(index)
+		lowlink[dir] = index[dir]
+		stack = append(stack, dir)
+		onStack[dir] = true
+
+		selfReference := false
+		for _, target := range g.Nodes[dir].Kustomizations() {
+			if _, ok := g.Nodes[target]; !ok {
+				continue
+			}
+			if target == dir {
+				selfReference = true
+			}
+			if _, visited := index[target]; !visited {
+				visit(target)
+				lowlink[dir] = min(lowlink[dir], lowlink[target])
+			} else if onStack[target] {
+				lowlink[dir] = min(lowlink[dir], index[target])
+			}
+		}
+
+		if lowlink[dir] != index[dir] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == dir {
+				break
+			}
+		}
+		if len(component) > 1 || selfReference {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, dir := range g.Dirs() {
+		if _, visited := index[dir]; !visited {
+			visit(dir)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// UnreferencedBases returns the kustomizations that look like bases but are not
+// referenced by any other kustomization, sorted. Components and kustomizations in
+// a directory named base or bases, or below one, are considered bases.
+func (g *Graph) UnreferencedBases() []string {
+	var unreferenced []string
+	for _, dir := range g.Roots() {
+		if g.Nodes[dir].Kind == KindComponent || g.isBaseDir(dir) {
+			unreferenced = append(unreferenced, dir)
+		}
+	}
+	return unreferenced
+}
+
+// isBaseDir reports whether the directory or one of its parents below the root is named like a base
+func (g *Graph) isBaseDir(dir string) bool {
+	rel, err := filepath.Rel(g.Root, dir)
+	if err != nil {
+		return false
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+		for _, name := range baseDirNames {
+			if segment == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Outside returns the references to files and directories outside of the root
+// directory, sorted by the referencing kustomization
+func (g *Graph) Outside() []Edge {
+	var edges []Edge
+	for _, dir := range g.Dirs() {
+		for _, ref := range g.Nodes[dir].References {
+			if ref.Outside {
+				edges = append(edges, Edge{From: dir, Reference: ref})
+			}
+		}
+	}
+	return edges
+}
+
+// Remote returns the references to remote resources, sorted by the referencing kustomization
+func (g *Graph) Remote() []Edge {
+	var edges []Edge
+	for _, dir := range g.Dirs() {
+		for _, ref := range g.Nodes[dir].References {
+			if ref.Remote {
+				edges = append(edges, Edge{From: dir, Reference: ref})
+			}
+		}
+	}
+	return edges
+}
+
+// Rel returns the path relative to the root directory for display, slash separated
+func (g *Graph) Rel(path string) string {
+	rel, err := filepath.Rel(g.Root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
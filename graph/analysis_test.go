@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/internal/testutil"
+)
+
+// buildTree writes the files and builds the graph of their kustomizations
+func buildTree(t *testing.T, files map[string]string) (*Graph, func(string) string) {
+	t.Helper()
+	root := testutil.WriteTree(t, files)
+	var kustomizations []string
+	for name := range files {
+		if filepath.Base(name) == "kustomization.yaml" {
+			kustomizations = append(kustomizations, filepath.Join(root, name))
+		}
+	}
+	return Build(root, kustomizations), func(name string) string { return filepath.Join(root, name) }
+}
+
+func TestGraph_Cycles(t *testing.T) {
+	g, dir := buildTree(t, map[string]string{
+		"a/kustomization.yaml":    "resources:\n  - ../b\n",
+		"b/kustomization.yaml":    "resources:\n  - ../c\n",
+		"c/kustomization.yaml":    "resources:\n  - ../a\n",
+		"self/kustomization.yaml": "resources:\n  - .\n",
+		"root/kustomization.yaml": "resources:\n  - ../a\n",
+	})
+	want := [][]string{{dir("a"), dir("b"), dir("c")}, {dir("self")}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected cycles %v, got: %v", want, got)
+	}
+
+	g, _ = buildTree(t, treeExample)
+	if got := g.Cycles(); len(got) != 0 {
+		t.Errorf("expected no cycles, got: %v", got)
+	}
+}
+
+func TestGraph_UnreferencedBases(t *testing.T) {
+	g, dir := buildTree(t, map[string]string{
+		"apps/nginx/base/kustomization.yaml":         "resources: []\n",
+		"apps/nginx/overlays/dev/kustomization.yaml": "resources:\n  - ../../base\n",
+		"apps/redis/base/kustomization.yaml":         "resources: []\n",
+		"bases/monitoring/kustomization.yaml":        "resources: []\n",
+		"components/tls/kustomization.yaml":          "kind: Component\n",
+		"clusters/prod/kustomization.yaml":           "resources: []\n",
+	})
+	want := []string{dir("apps/redis/base"), dir("bases/monitoring"), dir("components/tls")}
+	if got := g.UnreferencedBases(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected unreferenced bases %v, got: %v", want, got)
+	}
+}
+
+func TestGraph_Outside(t *testing.T) {
+	g, dir := buildTree(t, treeExample)
+	outside := g.Outside()
+	if len(outside) != 1 || outside[0].From != dir("overlays/prod") || outside[0].Path != "../../../shared" {
+		t.Errorf("unexpected outside references: %+v", outside)
+	}
+	remote := g.Remote()
+	if len(remote) != 1 || remote[0].From != dir("overlays/dev") {
+		t.Errorf("unexpected remote references: %+v", remote)
+	}
+}
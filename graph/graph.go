@@ -0,0 +1,287 @@
+// Package graph provides the graph of the references between kustomizations.
+//
+// Every discovered kustomization is a node. Its resources, components and bases
+// entries are references to other kustomizations, to plain resource files or to
+// remote resources. Kustomizations no other kustomization references are roots,
+// typically the overlays deployed to a cluster.
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// Fields of a kustomization referencing other kustomizations or resources
+const (
+	FieldResources  = "resources"
+	FieldComponents = "components"
+	// FieldBases is the deprecated predecessor of resources
+	FieldBases = "bases"
+)
+
+// Kinds of kustomizations
+const (
+	KindKustomization = "Kustomization"
+	KindComponent     = "Component"
+)
+
+// Role is the position of a kustomization in the graph
+type Role string
+
+const (
+	// RoleRoot is a kustomization no other kustomization references
+	RoleRoot Role = "root"
+	// RoleBase is a kustomization referenced as resource or base
+	RoleBase Role = "base"
+	// RoleComponent is a kustomization referenced as component
+	RoleComponent Role = "component"
+)
+
+// Graph contains the references between the kustomizations below a root directory
+type Graph struct {
+	// Root is the directory the kustomizations were discovered in
+	Root string
+	// Nodes are the kustomizations by their directory
+	Nodes map[string]*Node
+}
+
+// Node is a single kustomization
+type Node struct {
+	// Dir is the directory of the kustomization
+	Dir string
+	// File is the kustomization file within Dir
+	File string
+	// Kind is the kind of the kustomization, Kustomization or Component
+	Kind string
+	// References are the entries of the resources, components and bases fields in their order
+	References []Reference
+	// ReferencedBy are the directories of the kustomizations referencing this one, sorted
+	ReferencedBy []string
+	// Files are the files and directories other than kustomizations referenced by any field
+	// of the kustomization, e.g. resources, patches and generator sources, sorted. Missing
+	// local paths are included, so the deletion of a referenced file affects the kustomization.
+	Files []string
+	// Err is set if the kustomization file could not be parsed, the node has no references then
+	Err error
+}
+
+// Reference is a single entry of the resources, components or bases field
+type Reference struct {
+	// Field is the field of the entry, see FieldResources
+	Field string
+	// Path is the entry as written in the kustomization file
+	Path string
+	// Target is the referenced file or directory, empty for remote references
+	Target string
+	// Kustomization is set if the target is a directory containing a kustomization file
+	Kustomization bool
+	// Remote is set for URLs and git repositories
+	Remote bool
+	// Outside is set if the target is located outside of the root directory
+	Outside bool
+}
+
+// Build parses the given kustomization files discovered below root and links
+// their references. Kustomization files that cannot be parsed are added as nodes
+// without references, so the build reports their errors.
+func Build(root string, files []string) *Graph {
+	g := &Graph{Root: filepath.Clean(root), Nodes: map[string]*Node{}}
+	for _, file := range files {
+		node := g.parse(file)
+		g.Nodes[node.Dir] = node
+	}
+	for _, dir := range g.Dirs() {
+		for _, ref := range g.Nodes[dir].References {
+			if target, ok := g.Nodes[ref.Target]; ok && ref.Kustomization && target.Dir != dir {
+				target.ReferencedBy = append(target.ReferencedBy, dir)
+			}
+		}
+	}
+	for _, node := range g.Nodes {
+		node.ReferencedBy = dedupe(node.ReferencedBy)
+	}
+	return g
+}
+
+// parse reads the kustomization file and resolves its references
+func (g *Graph) parse(file string) *Node {
+	node := &Node{Dir: filepath.Dir(file), File: file, Kind: KindKustomization}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		node.Err = err
+		return node
+	}
+	var kustomization struct {
+		Kind       string   `yaml:"kind"`
+		Resources  []string `yaml:"resources"`
+		Components []string `yaml:"components"`
+		Bases      []string `yaml:"bases"`
+	}
+	if err := yaml.Unmarshal(content, &kustomization); err != nil {
+		node.Err = fmt.Errorf("failed to parse %s: %w", file, err)
+		return node
+	}
+	if kustomization.Kind != "" {
+		node.Kind = kustomization.Kind
+	}
+	var fields any
+	if err := yaml.Unmarshal(content, &fields); err == nil {
+		node.Files = referencedFiles(node.Dir, fields)
+	}
+	for _, field := range []struct {
+		name  string
+		paths []string
+	}{
+		{FieldResources, kustomization.Resources},
+		{FieldComponents, kustomization.Components},
+		{FieldBases, kustomization.Bases},
+	} {
+		for _, path := range field.paths {
+			node.References = append(node.References, g.resolve(node.Dir, field.name, path))
+		}
+	}
+	return node
+}
+
+// resolve resolves the entry of a field of the kustomization in dir
+func (g *Graph) resolve(dir, field, path string) Reference {
+	ref := Reference{Field: field, Path: path}
+	target := filepath.Join(dir, path)
+	if _, err := os.Stat(target); err != nil && isRemote(path) {
+		ref.Remote = true
+		return ref
+	}
+	ref.Target = target
+	ref.Kustomization = validate.KustomizationFile(target) != ""
+	ref.Outside = !g.contains(target)
+	return ref
+}
+
+// referencedFiles returns the files and directories other than kustomizations the string
+// values of the parsed kustomization refer to, relative to dir. Values of the form key=path,
+// as used by generators, refer to the path. Values not referring to an existing path are
+// included if they look like a local path, e.g., a deleted resource file.
+func referencedFiles(dir string, value any) []string {
+	var files []string
+	switch v := value.(type) {
+	case map[string]any:
+		for _, item := range v {
+			files = append(files, referencedFiles(dir, item)...)
+		}
+	case []any:
+		for _, item := range v {
+			files = append(files, referencedFiles(dir, item)...)
+		}
+	case string:
+		if v == "" || strings.ContainsAny(v, "\n") {
+			return nil
+		}
+		if _, path, ok := strings.Cut(v, "="); ok {
+			v = path
+		}
+		path := filepath.Join(dir, v)
+		if path == dir || validate.KustomizationFile(path) != "" {
+			return nil
+		}
+		if _, err := os.Stat(path); err == nil || isLocalPath(v) {
+			files = append(files, path)
+		}
+	}
+	return dedupe(files)
+}
+
+// isLocalPath reports whether the value looks like the path of a local file or directory,
+// i.e., it has a directory or a file extension and is neither remote nor an image reference
+func isLocalPath(value string) bool {
+	if isRemote(value) || strings.ContainsAny(value, " :@") {
+		return false
+	}
+	return strings.Contains(value, "/") || filepath.Ext(value) != ""
+}
+
+// contains reports whether the path is located within the root directory
+func (g *Graph) contains(path string) bool {
+	rel, err := filepath.Rel(g.Root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Node returns the kustomization in the given directory, nil if it is not part of the graph
+func (g *Graph) Node(dir string) *Node {
+	return g.Nodes[filepath.Clean(dir)]
+}
+
+// Dirs returns the directories of all kustomizations, sorted
+func (g *Graph) Dirs() []string {
+	dirs := make([]string, 0, len(g.Nodes))
+	for dir := range g.Nodes {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// Roots returns the directories of the kustomizations no other kustomization references, sorted
+func (g *Graph) Roots() []string {
+	var roots []string
+	for _, dir := range g.Dirs() {
+		if g.Nodes[dir].IsRoot() {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// IsRoot reports whether no other kustomization references the kustomization
+func (n *Node) IsRoot() bool {
+	return len(n.ReferencedBy) == 0
+}
+
+// Role returns the position of the kustomization in the graph
+func (n *Node) Role() Role {
+	switch {
+	case n.IsRoot():
+		return RoleRoot
+	case n.Kind == KindComponent:
+		return RoleComponent
+	default:
+		return RoleBase
+	}
+}
+
+// Kustomizations returns the directories of the local kustomizations referenced by the kustomization
+func (n *Node) Kustomizations() []string {
+	var dirs []string
+	for _, ref := range n.References {
+		if ref.Kustomization {
+			dirs = append(dirs, ref.Target)
+		}
+	}
+	return dedupe(dirs)
+}
+
+// isRemote reports whether the entry refers to a remote resource, a URL or a git repository
+func isRemote(path string) bool {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "git@") || strings.Contains(path, "?ref=") {
+		return true
+	}
+	host, _, found := strings.Cut(path, "/")
+	return found && strings.Contains(host, ".") && !strings.HasPrefix(host, ".")
+}
+
+// dedupe sorts the values and removes duplicates
+func dedupe(values []string) []string {
+	sort.Strings(values)
+	unique := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/internal/testutil"
+)
+
+var treeExample = map[string]string{
+	"base/kustomization.yaml":       "resources:\n  - deployment.yaml\n",
+	"base/deployment.yaml":          "kind: Deployment\n",
+	"monitoring/kustomization.yaml": "apiVersion: kustomize.config.k8s.io/v1alpha1\nkind: Component\n",
+	"overlays/dev/kustomization.yaml": "resources:\n  - ../../base\n" +
+		"  - https://github.com/example/app//config?ref=v1.0.0\n" +
+		"components:\n  - ../../monitoring\n",
+	"overlays/prod/kustomization.yaml": "bases:\n  - ../../base\n  - ../../base\nresources:\n  - ../../../shared\n",
+	"broken/kustomization.yaml":        "resources: [\n",
+}
+
+func TestBuild(t *testing.T) {
+	root := testutil.WriteTree(t, treeExample)
+	var files []string
+	for name := range treeExample {
+		if filepath.Base(name) == "kustomization.yaml" {
+			files = append(files, filepath.Join(root, name))
+		}
+	}
+	g := Build(root, files)
+
+	dir := func(name string) string { return filepath.Join(root, name) }
+	if got, want := g.Roots(), []string{dir("broken"), dir("overlays/dev"), dir("overlays/prod")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected roots %v, got: %v", want, got)
+	}
+
+	tests := []struct {
+		dir            string
+		role           Role
+		referencedBy   []string
+		kustomizations []string
+	}{
+		{dir: "base", role: RoleBase, referencedBy: []string{dir("overlays/dev"), dir("overlays/prod")}},
+		{dir: "monitoring", role: RoleComponent, referencedBy: []string{dir("overlays/dev")}},
+		{dir: "overlays/dev", role: RoleRoot, kustomizations: []string{dir("base"), dir("monitoring")}},
+		{dir: "overlays/prod", role: RoleRoot, kustomizations: []string{dir("base")}},
+		{dir: "broken", role: RoleRoot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			node := g.Node(dir(tt.dir))
+			if node == nil {
+				t.Fatalf("expected node for %s", tt.dir)
+			}
+			if node.Role() != tt.role {
+				t.Errorf("expected role %s, got: %s", tt.role, node.Role())
+			}
+			if !reflect.DeepEqual(node.ReferencedBy, tt.referencedBy) {
+				t.Errorf("expected referenced by %v, got: %v", tt.referencedBy, node.ReferencedBy)
+			}
+			if got := node.Kustomizations(); !reflect.DeepEqual(got, tt.kustomizations) {
+				t.Errorf("expected kustomizations %v, got: %v", tt.kustomizations, got)
+			}
+		})
+	}
+
+	if g.Node(dir("broken")).Err == nil {
+		t.Errorf("expected parse error for the broken kustomization")
+	}
+	dev := g.Node(dir("overlays/dev")).References
+	if len(dev) != 3 || !dev[1].Remote || dev[1].Target != "" || dev[2].Field != FieldComponents {
+		t.Errorf("unexpected references of overlays/dev: %+v", dev)
+	}
+	prod := g.Node(dir("overlays/prod")).References
+	if len(prod) != 3 || !prod[0].Outside || prod[0].Kustomization || prod[1].Field != FieldBases {
+		t.Errorf("unexpected references of overlays/prod: %+v", prod)
+	}
+}
+
+func Test_isRemote(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "https://github.com/example/app//config?ref=v1.0.0", want: true},
+		{path: "github.com/example/app/config?ref=main", want: true},
+		{path: "git@github.com:example/app.git", want: true},
+		{path: "gitlab.example.com/team/app", want: true},
+		{path: "../../base", want: false},
+		{path: "deployment.yaml", want: false},
+		{path: "./base", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isRemote(tt.path); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// vertex is a node of the rendered graph, a kustomization or the target of an
+// outside or remote reference
+type vertex struct {
+	id    string
+	label string
+	// kind is the kind of the kustomization, empty for targets of references
+	kind         string
+	remote       bool
+	outside      bool
+	unreferenced bool
+	cycle        bool
+}
+
+// link is an edge of the rendered graph
+type link struct {
+	from, to string
+	field    string
+	cycle    bool
+}
+
+// view returns the vertices and links of the rendered graph. Local resource
+// files are omitted, only kustomizations and the targets of outside and remote
+// references are rendered.
+func (g *Graph) view() ([]vertex, []link) {
+	// cycle numbers the cycles of the kustomizations from 1, kustomizations without cycle have none
+	cycle := map[string]int{}
+	for i, dirs := range g.Cycles() {
+		for _, dir := range dirs {
+			cycle[dir] = i + 1
+		}
+	}
+	unreferenced := map[string]bool{}
+	for _, dir := range g.UnreferencedBases() {
+		unreferenced[dir] = true
+	}
+
+	var vertices []vertex
+	var links []link
+	seen := map[string]bool{}
+	for _, dir := range g.Dirs() {
+		node := g.Nodes[dir]
+		id := g.Rel(dir)
+		seen[id] = true
+		vertices = append(vertices, vertex{id: id, label: id, kind: node.Kind, unreferenced: unreferenced[dir], cycle: cycle[dir] > 0})
+	}
+	for _, dir := range g.Dirs() {
+		for _, ref := range g.Nodes[dir].References {
+			var target vertex
+			switch {
+			case ref.Remote:
+				target = vertex{id: ref.Path, label: ref.Path, remote: true}
+			case ref.Outside:
+				target = vertex{id: g.Rel(ref.Target), label: g.Rel(ref.Target), outside: true}
+			case ref.Kustomization && g.Nodes[ref.Target] != nil:
+				target = vertex{id: g.Rel(ref.Target)}
+			default:
+				continue
+			}
+			if !seen[target.id] {
+				seen[target.id] = true
+				vertices = append(vertices, target)
+			}
+			links = append(links, link{
+				from:  g.Rel(dir),
+				to:    target.id,
+				field: ref.Field,
+				// edges between two cycles are not part of either
+				cycle: cycle[dir] > 0 && cycle[dir] == cycle[ref.Target],
+			})
+		}
+	}
+	return vertices, links
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz. Components are drawn
+// as component shapes, remote resources as notes, outside references dashed,
+// unreferenced bases orange and cycles red.
+func WriteDOT(w io.Writer, g *Graph) error {
+	vertices, links := g.view()
+	var b strings.Builder
+	b.WriteString("digraph kustomizations {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, v := range vertices {
+		var attrs []string
+		switch {
+		case v.remote:
+			attrs = append(attrs, "shape=note", "style=dashed")
+		case v.outside:
+			attrs = append(attrs, "style=dashed", "color=orange", `xlabel="outside root"`)
+		case v.kind == KindComponent:
+			attrs = append(attrs, "shape=component")
+		}
+		if v.unreferenced {
+			attrs = append(attrs, "color=orange", `xlabel="unreferenced"`)
+		}
+		if v.cycle {
+			attrs = append(attrs, "color=red", `xlabel="cycle"`)
+		}
+		fmt.Fprintf(&b, "  %s", strconv.Quote(v.id))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	for _, l := range links {
+		attrs := []string{"label=" + strconv.Quote(l.field)}
+		if l.cycle {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", strconv.Quote(l.from), strconv.Quote(l.to), strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as Mermaid flowchart, with the same highlighting as WriteDOT
+func WriteMermaid(w io.Writer, g *Graph) error {
+	vertices, links := g.view()
+	ids := map[string]string{}
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	classes := map[string][]string{}
+	for i, v := range vertices {
+		id := fmt.Sprintf("n%d", i)
+		ids[v.id] = id
+		label := strings.ReplaceAll(v.label, `"`, "#quot;")
+		switch {
+		case v.remote:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+			classes["remote"] = append(classes["remote"], id)
+		case v.kind == KindComponent:
+			fmt.Fprintf(&b, "  %s[[\"%s\"]]\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		}
+		switch {
+		case v.cycle:
+			classes["cycle"] = append(classes["cycle"], id)
+		case v.outside:
+			classes["outside"] = append(classes["outside"], id)
+		case v.unreferenced:
+			classes["unreferenced"] = append(classes["unreferenced"], id)
+		}
+	}
+	var cycleLinks []string
+	for i, l := range links {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[l.from], l.field, ids[l.to])
+		if l.cycle {
+			cycleLinks = append(cycleLinks, strconv.Itoa(i))
+		}
+	}
+	styles := []struct{ class, style string }{
+		{"remote", "stroke-dasharray:3"},
+		{"outside", "stroke:#e69500,stroke-dasharray:5"},
+		{"unreferenced", "stroke:#e69500"},
+		{"cycle", "stroke:#d00"},
+	}
+	for _, s := range styles {
+		if len(classes[s.class]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  classDef %s %s\n", s.class, s.style)
+		fmt.Fprintf(&b, "  class %s %s\n", strings.Join(classes[s.class], ","), s.class)
+	}
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#d00\n", strings.Join(cycleLinks, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type jsonGraph struct {
+	Root              string     `json:"root"`
+	Kustomizations    []jsonNode `json:"kustomizations"`
+	Cycles            [][]string `json:"cycles"`
+	UnreferencedBases []string   `json:"unreferencedBases"`
+	OutsideReferences []jsonEdge `json:"outsideReferences"`
+	RemoteReferences  []jsonEdge `json:"remoteReferences"`
+}
+
+type jsonNode struct {
+	Path         string          `json:"path"`
+	File         string          `json:"file"`
+	Kind         string          `json:"kind"`
+	Role         Role            `json:"role"`
+	References   []jsonReference `json:"references"`
+	ReferencedBy []string        `json:"referencedBy"`
+	Error        string          `json:"error,omitempty"`
+}
+
+type jsonReference struct {
+	Field         string `json:"field"`
+	Path          string `json:"path"`
+	Target        string `json:"target,omitempty"`
+	Kustomization bool   `json:"kustomization"`
+	Remote        bool   `json:"remote"`
+	Outside       bool   `json:"outside"`
+}
+
+type jsonEdge struct {
+	From string `json:"from"`
+	Path string `json:"path"`
+}
+
+// WriteJSON writes the graph as a single indented JSON document. All paths are
+// relative to the root directory.
+func WriteJSON(w io.Writer, g *Graph) error {
+	out := jsonGraph{
+		Root:              g.Root,
+		Kustomizations:    []jsonNode{},
+		Cycles:            [][]string{},
+		UnreferencedBases: []string{},
+		OutsideReferences: []jsonEdge{},
+		RemoteReferences:  []jsonEdge{},
+	}
+	for _, dir := range g.Dirs() {
+		node := g.Nodes[dir]
+		n := jsonNode{
+			Path:         g.Rel(dir),
+			File:         g.Rel(node.File),
+			Kind:         node.Kind,
+			Role:         node.Role(),
+			References:   []jsonReference{},
+			ReferencedBy: []string{},
+		}
+		if node.Err != nil {
+			n.Error = node.Err.Error()
+		}
+		for _, ref := range node.References {
+			r := jsonReference{Field: ref.Field, Path: ref.Path, Kustomization: ref.Kustomization, Remote: ref.Remote, Outside: ref.Outside}
+			if ref.Target != "" {
+				r.Target = g.Rel(ref.Target)
+			}
+			n.References = append(n.References, r)
+		}
+		for _, referrer := range node.ReferencedBy {
+			n.ReferencedBy = append(n.ReferencedBy, g.Rel(referrer))
+		}
+		out.Kustomizations = append(out.Kustomizations, n)
+	}
+	for _, cycle := range g.Cycles() {
+		var rel []string
+		for _, dir := range cycle {
+			rel = append(rel, g.Rel(dir))
+		}
+		out.Cycles = append(out.Cycles, rel)
+	}
+	for _, dir := range g.UnreferencedBases() {
+		out.UnreferencedBases = append(out.UnreferencedBases, g.Rel(dir))
+	}
+	for _, edge := range g.Outside() {
+		out.OutsideReferences = append(out.OutsideReferences, jsonEdge{From: g.Rel(edge.From), Path: edge.Path})
+	}
+	for _, edge := range g.Remote() {
+		out.RemoteReferences = append(out.RemoteReferences, jsonEdge{From: g.Rel(edge.From), Path: edge.Path})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
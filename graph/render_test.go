@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	g, _ := buildTree(t, treeExample)
+	buf := &bytes.Buffer{}
+	if err := WriteDOT(buf, g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`"overlays/dev" -> "base" [label="resources"];`,
+		`"overlays/dev" -> "monitoring" [label="components"];`,
+		`"overlays/prod" -> "../shared" [label="resources"];`,
+		`"monitoring" [shape=component];`,
+		`"https://github.com/example/app//config?ref=v1.0.0" [shape=note, style=dashed];`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in:\n%s", want, buf.String())
+		}
+	}
+	// the duplicate bases entries are rendered as two edges, local files not at all
+	if got := strings.Count(buf.String(), `"overlays/prod" -> "base"`); got != 2 {
+		t.Errorf("expected 2 edges from overlays/prod to base, got: %d", got)
+	}
+	if strings.Contains(buf.String(), "deployment.yaml") {
+		t.Errorf("expected local resource files to be omitted:\n%s", buf.String())
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	g, _ := buildTree(t, map[string]string{
+		"a/kustomization.yaml": "resources:\n  - ../b\n",
+		"b/kustomization.yaml": "resources:\n  - ../a\n",
+	})
+	buf := &bytes.Buffer{}
+	if err := WriteMermaid(buf, g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "graph LR\n" +
+		"  n0[\"a\"]\n" +
+		"  n1[\"b\"]\n" +
+		"  n0 -->|resources| n1\n" +
+		"  n1 -->|resources| n0\n" +
+		"  classDef cycle stroke:#d00\n" +
+		"  class n0,n1 cycle\n" +
+		"  linkStyle 0,1 stroke:#d00\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestWriteMermaid_separateCycles(t *testing.T) {
+	g, _ := buildTree(t, map[string]string{
+		"a/kustomization.yaml": "resources:\n  - ../b\n",
+		"b/kustomization.yaml": "resources:\n  - ../a\n  - ../c\n",
+		"c/kustomization.yaml": "resources:\n  - ../d\n",
+		"d/kustomization.yaml": "resources:\n  - ../c\n",
+	})
+	buf := &bytes.Buffer{}
+	if err := WriteMermaid(buf, g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the edge from b to c connects the two cycles, but is not part of either
+	for _, want := range []string{"  n1 -->|resources| n2\n", "  linkStyle 0,1,3,4 stroke:#d00\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	g, _ := buildTree(t, treeExample)
+	buf := &bytes.Buffer{}
+	if err := WriteJSON(buf, g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonGraph
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got.Kustomizations) != 5 || got.Kustomizations[0].Path != "base" || got.Kustomizations[0].Role != RoleBase {
+		t.Errorf("unexpected kustomizations: %+v", got.Kustomizations)
+	}
+	if len(got.OutsideReferences) != 1 || got.OutsideReferences[0].From != "overlays/prod" {
+		t.Errorf("unexpected outside references: %+v", got.OutsideReferences)
+	}
+	if len(got.Cycles) != 0 || len(got.RemoteReferences) != 1 {
+		t.Errorf("unexpected cycles or remote references: %+v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldPath is the path to a field within a resource. Elements are either
+// map keys or list indices formatted as "[n]".
+type FieldPath []string
+
+// wildcard matches every key of a map or every item of a list in a field path pattern
+const wildcard = "*"
+
+// ParseFieldPath parses a dot separated field path, e.g. spec.containers[0].image.
+// Patterns may contain wildcards, [*] matches every list item and * every map key,
+// e.g. spec.template.spec.containers[*].image or metadata.labels.*
+func ParseFieldPath(s string) (FieldPath, error) {
+	var path FieldPath
+	for _, segment := range strings.Split(s, ".") {
+		key, rest, hasIndex := strings.Cut(segment, "[")
+		if key == "" && (len(path) == 0 || !hasIndex) {
+			return nil, fmt.Errorf("invalid field path %q: empty key", s)
+		}
+		if key != "" {
+			path = append(path, key)
+		}
+		for hasIndex {
+			index, remainder, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("invalid field path %q: missing ]", s)
+			}
+			if _, err := strconv.Atoi(index); err != nil && index != wildcard {
+				return nil, fmt.Errorf("invalid field path %q: invalid index %q", s, index)
+			}
+			path = append(path, "["+index+"]")
+			if remainder == "" {
+				break
+			}
+			if !strings.HasPrefix(remainder, "[") {
+				return nil, fmt.Errorf("invalid field path %q: unexpected %q after index", s, remainder)
+			}
+			rest = remainder[1:]
+		}
+	}
+	return path, nil
+}
+
+// Field is a field of a resource with its concrete path
+type Field struct {
+	Path  FieldPath
+	Value any
+}
+
+// Fields returns all fields of the parsed object matching the path pattern, in
+// the order of the object. Map keys are expanded in sorted order.
+func (r Resource) Fields(pattern FieldPath) []Field {
+	var fields []Field
+	var walk func(value any, path FieldPath, rest FieldPath)
+	walk = func(value any, path FieldPath, rest FieldPath) {
+		if len(rest) == 0 {
+			fields = append(fields, Field{Path: path, Value: value})
+			return
+		}
+		element := rest[0]
+		switch v := value.(type) {
+		case []any:
+			if !strings.HasPrefix(element, "[") {
+				return
+			}
+			index := strings.Trim(element, "[]")
+			for i, item := range v {
+				if index == wildcard || index == strconv.Itoa(i) {
+					walk(item, path.Index(i), rest[1:])
+				}
+			}
+		case map[string]any:
+			if element != wildcard {
+				if child, ok := v[element]; ok {
+					walk(child, path.Child(element), rest[1:])
+				}
+				return
+			}
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(v[key], path.Child(key), rest[1:])
+			}
+		}
+	}
+	if r.Object != nil {
+		walk(r.Object, FieldPath{}, pattern)
+	}
+	return fields
+}
+
+// Child returns the path of the given key below the path
+func (p FieldPath) Child(key string) FieldPath {
+	return append(p[:len(p):len(p)], key)
+}
+
+// Index returns the path of the given list index below the path
+func (p FieldPath) Index(i int) FieldPath {
+	return append(p[:len(p):len(p)], fmt.Sprintf("[%d]", i))
+}
+
+// String formats the path as dot separated keys, e.g. spec.containers[0].image
+func (p FieldPath) String() string {
+	var b strings.Builder
+	for i, element := range p {
+		if i > 0 && !strings.HasPrefix(element, "[") {
+			b.WriteString(".")
+		}
+		b.WriteString(element)
+	}
+	return b.String()
+}
+
+// Lookup returns the value at the keys within the nested maps, or nil if it does not exist
+func Lookup(value any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// LineOf returns the line of the given field within the content of the
+// resource, or 0 if the field does not exist
+func (r Resource) LineOf(path FieldPath) int {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(r.FileContent), &root); err != nil || len(root.Content) == 0 {
+		return 0
+	}
+
+	node := root.Content[0]
+	line := node.Line
+	for _, element := range path {
+		if strings.HasPrefix(element, "[") {
+			i, err := strconv.Atoi(strings.Trim(element, "[]"))
+			if err != nil || node.Kind != yaml.SequenceNode || i < 0 || i >= len(node.Content) {
+				return line
+			}
+			node = node.Content[i]
+			line = node.Line
+			continue
+		}
+
+		key, value := mappingValue(node, element)
+		if key == nil {
+			return line
+		}
+		node, line = value, key.Line
+	}
+	return line
+}
@@ -0,0 +1,119 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestResource_LineOf(t *testing.T) {
+	resource := Resource{FileContent: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: pod\nspec:\n  containers:\n  - name: app\n    image: nginx\n"}
+	tests := []struct {
+		path FieldPath
+		want int
+	}{
+		{path: FieldPath{"metadata", "name"}, want: 4},
+		{path: FieldPath{}.Child("spec").Child("containers").Index(0).Child("image"), want: 8},
+		// missing fields point to their closest existing parent
+		{path: FieldPath{"spec", "containers", "[0]", "ports"}, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path.String(), func(t *testing.T) {
+			if got := resource.LineOf(tt.path); got != tt.want {
+				t.Errorf("expected line %d, got: %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    FieldPath
+		wantErr bool
+	}{
+		{path: "spec.replicas", want: FieldPath{"spec", "replicas"}},
+		{path: "spec.template.spec.containers[*].image", want: FieldPath{"spec", "template", "spec", "containers", "[*]", "image"}},
+		{path: "spec.containers[0].args[1]", want: FieldPath{"spec", "containers", "[0]", "args", "[1]"}},
+		{path: "matrix[0][1]", want: FieldPath{"matrix", "[0]", "[1]"}},
+		{path: "metadata.labels.*", want: FieldPath{"metadata", "labels", "*"}},
+		{path: "spec..replicas", wantErr: true},
+		{path: "spec.containers[0", wantErr: true},
+		{path: "spec.containers[", wantErr: true},
+		{path: "spec.containers[first]", wantErr: true},
+		{path: "spec.containers[0]image", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseFieldPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	object := map[string]any{"spec": map[string]any{"replicas": 2, "containers": []any{}}}
+	tests := []struct {
+		name string
+		keys []string
+		want any
+	}{
+		{name: "no keys", want: object},
+		{name: "nested", keys: []string{"spec", "replicas"}, want: 2},
+		{name: "missing", keys: []string{"spec", "template"}, want: nil},
+		{name: "below a list", keys: []string{"spec", "containers", "name"}, want: nil},
+		{name: "below a scalar", keys: []string{"spec", "replicas", "value"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lookup(object, tt.keys...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResource_Fields(t *testing.T) {
+	resource := Resource{Object: map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]any{"app": "web", "tier": "frontend"},
+		},
+		"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "app", "image": "nginx:latest"},
+				map[string]any{"name": "sidecar"},
+				map[string]any{"name": "proxy", "image": "envoy:1.30"},
+			},
+		},
+	}}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "spec.containers[*].image", want: []string{"spec.containers[0].image=nginx:latest", "spec.containers[2].image=envoy:1.30"}},
+		{path: "spec.containers[1].name", want: []string{"spec.containers[1].name=sidecar"}},
+		{path: "metadata.labels.*", want: []string{"metadata.labels.app=web", "metadata.labels.tier=frontend"}},
+		{path: "spec.containers.image"},
+		{path: "spec.volumes[*].name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParseFieldPath(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, field := range resource.Fields(path) {
+				got = append(got, fmt.Sprintf("%s=%v", field.Path, field.Value))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// OriginAnnotation is set by kustomize on every resource if the originAnnotations
+	// build metadata option is enabled and records the source the resource was loaded from
+	OriginAnnotation = "config.kubernetes.io/origin"
+	// TransformationsAnnotation is set by kustomize on every resource if the transformerAnnotations
+	// build metadata option is enabled and records the transformers that modified the resource
+	TransformationsAnnotation = "alpha.config.kubernetes.io/transformations"
+)
+
+// origin is the content of the origin and transformations annotations
+type origin struct {
+	Path         string `yaml:"path"`
+	Repo         string `yaml:"repo"`
+	Ref          string `yaml:"ref"`
+	ConfiguredIn string `yaml:"configuredIn"`
+}
+
+// annotatedDocument is the part of a rendered document holding the annotations
+type annotatedDocument struct {
+	Metadata struct {
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+}
+
+// resolveOrigin reads the origin and transformations annotations of the given document
+// and returns the source file of the resource and the patch files of all kustomizations
+// that transformed it. Paths in the annotations are relative to originRoot, the returned
+// paths are relative to cwd.
+func resolveOrigin(doc, originRoot, cwd string) (string, []string) {
+	var annotated annotatedDocument
+	if err := yaml.Unmarshal([]byte(doc), &annotated); err != nil {
+		return "", nil
+	}
+	annotations := annotated.Metadata.Annotations
+
+	var source string
+	if value, ok := annotations[OriginAnnotation]; ok {
+		var o origin
+		if err := yaml.Unmarshal([]byte(value), &o); err == nil {
+			switch {
+			case o.Repo != "":
+				// remote resources are not available locally, so just record where they come from
+				source = o.Repo + "//" + o.Path
+				if o.Ref != "" {
+					source += "?ref=" + o.Ref
+				}
+			case o.Path != "":
+				source = relativeTo(filepath.Join(originRoot, o.Path), cwd)
+			case o.ConfiguredIn != "":
+				// generated resources have no file of their own, point to their generator
+				source = relativeTo(filepath.Join(originRoot, o.ConfiguredIn), cwd)
+			}
+		}
+	}
+
+	var patches []string
+	if value, ok := annotations[TransformationsAnnotation]; ok {
+		var transformations []origin
+		if err := yaml.Unmarshal([]byte(value), &transformations); err == nil {
+			seen := map[string]bool{}
+			for _, t := range transformations {
+				if t.Repo != "" || t.ConfiguredIn == "" || seen[t.ConfiguredIn] {
+					continue
+				}
+				seen[t.ConfiguredIn] = true
+				for _, patch := range patchFiles(filepath.Join(originRoot, t.ConfiguredIn)) {
+					patches = append(patches, relativeTo(patch, cwd))
+				}
+			}
+		}
+	}
+	return source, patches
+}
+
+// patchFiles returns the paths of all patch files referenced by the given kustomization file
+func patchFiles(kustomizationFile string) []string {
+	content, err := os.ReadFile(kustomizationFile)
+	if err != nil {
+		return nil
+	}
+
+	var kustomization struct {
+		Patches []struct {
+			Path string `yaml:"path"`
+		} `yaml:"patches"`
+		PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+		PatchesJson6902       []struct {
+			Path string `yaml:"path"`
+		} `yaml:"patchesJson6902"`
+	}
+	if err := yaml.Unmarshal(content, &kustomization); err != nil {
+		return nil
+	}
+
+	dir := filepath.Dir(kustomizationFile)
+	var files []string
+	for _, p := range kustomization.Patches {
+		if p.Path != "" {
+			files = append(files, filepath.Join(dir, p.Path))
+		}
+	}
+	for _, p := range kustomization.PatchesStrategicMerge {
+		// strategic merge patches may also be inlined
+		if !strings.Contains(p, "\n") {
+			files = append(files, filepath.Join(dir, p))
+		}
+	}
+	for _, p := range kustomization.PatchesJson6902 {
+		if p.Path != "" {
+			files = append(files, filepath.Join(dir, p.Path))
+		}
+	}
+	return files
+}
+
+// relativeTo returns path relative to base. Paths outside of base are returned as absolute paths.
+func relativeTo(path, base string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return rel
+}
+
+// StripOriginAnnotations removes the origin and transformations annotations
+// added by kustomize from the given rendered kustomize output
+func StripOriginAnnotations(stdout string) string {
+	documents := strings.Split(stdout, "\n---\n")
+	for i, doc := range documents {
+		documents[i] = stripAnnotations(doc, OriginAnnotation, TransformationsAnnotation)
+	}
+	return strings.Join(documents, "\n---\n")
+}
+
+// stripAnnotations removes the given annotations from a single rendered document.
+// The document is edited line by line instead of being re-encoded, so the remaining
+// lines keep the formatting of the kustomize output. The annotations key itself is
+// removed if no annotation is left.
+func stripAnnotations(doc string, keys ...string) string {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil || len(root.Content) == 0 {
+		return doc
+	}
+	_, metadata := mappingValue(root.Content[0], "metadata")
+	annotationsKey, annotations := mappingValue(metadata, "annotations")
+	if annotations == nil || annotations.Kind != yaml.MappingNode {
+		return doc
+	}
+
+	lines := strings.Split(doc, "\n")
+	remove := map[int]bool{}
+	removed := 0
+	for i := 0; i+1 < len(annotations.Content); i += 2 {
+		key := annotations.Content[i]
+		if !containsString(keys, key.Value) {
+			continue
+		}
+		removed++
+		// the annotation spans its key line and all following lines that are indented deeper
+		start := key.Line - 1
+		remove[start] = true
+		for j := start + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) != "" && indentOf(lines[j]) <= key.Column {
+				break
+			}
+			remove[j] = true
+		}
+	}
+	if removed == 0 {
+		return doc
+	}
+	if removed*2 == len(annotations.Content) {
+		remove[annotationsKey.Line-1] = true
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !remove[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// mappingValue returns the key and value node of the given key in a mapping node
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// indentOf returns the 1-based column of the first non-space character of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " ")) + 1
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var annotatedExample = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    alpha.config.kubernetes.io/transformations: |
+      - configuredIn: ../repo/overlays/prod/kustomization.yaml
+        configuredBy:
+          apiVersion: builtin
+          kind: PatchTransformer
+    config.kubernetes.io/origin: |
+      path: ../repo/base/deployment.yaml
+  name: my-app
+spec:
+  replicas: 1`
+
+func Test_stripAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "only origin annotations",
+			doc:  annotatedExample,
+			want: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\nspec:\n  replicas: 1",
+		},
+		{
+			name: "keeps other annotations",
+			doc:  "kind: Pod\nmetadata:\n  annotations:\n    config.kubernetes.io/origin: |\n      path: pod.yaml\n    team: a\n  name: pod",
+			want: "kind: Pod\nmetadata:\n  annotations:\n    team: a\n  name: pod",
+		},
+		{
+			name: "no annotations",
+			doc:  "kind: Pod\nmetadata:\n  name: pod",
+			want: "kind: Pod\nmetadata:\n  name: pod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripAnnotations(tt.doc, OriginAnnotation, TransformationsAnnotation); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_resolveOrigin(t *testing.T) {
+	root := t.TempDir()
+	overlay := filepath.Join(root, "repo", "overlays", "prod")
+	if err := os.MkdirAll(overlay, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	kustomization := "resources:\n- ../../base\npatches:\n- path: patch-memory.yaml\npatchesStrategicMerge:\n- patch-cpu.yaml\n"
+	if err := os.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte(kustomization), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origin, patches := resolveOrigin(annotatedExample, filepath.Join(root, "wrapper"), root)
+	if origin != filepath.Join("repo", "base", "deployment.yaml") {
+		t.Errorf("unexpected origin: %s", origin)
+	}
+	want := []string{
+		filepath.Join("repo", "overlays", "prod", "patch-memory.yaml"),
+		filepath.Join("repo", "overlays", "prod", "patch-cpu.yaml"),
+	}
+	if len(patches) != len(want) || patches[0] != want[0] || patches[1] != want[1] {
+		t.Errorf("expected patches %v, got: %v", want, patches)
+	}
+}
@@ -17,20 +17,33 @@ type KubernetesResource struct {
 }
 
 type Resource struct {
-	ApiVersion  string
-	Kind        string
-	Name        string
-	Namespace   string
-	SourcePath  string
-	FileContent string
+	ApiVersion  string `json:"apiVersion"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	SourcePath  string `json:"sourcePath"`
+	FileContent string `json:"content"`
+	// Origin is the source file the resource was loaded from, relative to the working directory
+	Origin string `json:"origin,omitempty"`
+	// Patches are the patch files of all kustomizations that transformed the resource
+	Patches []string `json:"patches,omitempty"`
+	// Object is the parsed content of the resource
+	Object map[string]any `json:"-"`
 }
 
-// ParseKustomizeOutput parses the kustomize output and returns a list of Resources
-func ParseKustomizeOutput(stdout, sourcePath, cwd string) []Resource {
+// ParseKustomizeOutput parses the kustomize output and returns a list of Resources.
+// The origin of every resource is resolved from the kustomize origin and transformer
+// annotations, whose paths are relative to originRoot. The annotations are stripped from
+// the content of the returned resources. If originRoot is empty, sourcePath is used.
+func ParseKustomizeOutput(stdout, sourcePath, originRoot, cwd string) []Resource {
 	if stdout == "" {
 		return []Resource{}
 	}
 
+	if originRoot == "" {
+		originRoot = sourcePath
+	}
+
 	var resources []Resource
 	documents := strings.Split(stdout, "---")
 
@@ -60,15 +73,33 @@ func ParseKustomizeOutput(stdout, sourcePath, cwd string) []Resource {
 			relativePath = sourcePath
 		}
 
+		origin, patches := resolveOrigin(doc, originRoot, cwd)
+		content := stripAnnotations(doc, OriginAnnotation, TransformationsAnnotation)
+		var object map[string]any
+		if err := yaml.Unmarshal([]byte(content), &object); err != nil {
+			continue
+		}
+
 		resources = append(resources, Resource{
 			ApiVersion:  resource.ApiVersion,
 			Kind:        resource.Kind,
 			Name:        resource.Metadata.Name,
 			Namespace:   namespace,
 			SourcePath:  relativePath,
-			FileContent: doc,
+			FileContent: content,
+			Origin:      origin,
+			Patches:     patches,
+			Object:      object,
 		})
 	}
 
 	return resources
 }
+
+// Annotation returns the value of the annotation with the given key, or an empty string if it is not set
+func (r Resource) Annotation(key string) string {
+	metadata, _ := r.Object["metadata"].(map[string]any)
+	annotations, _ := metadata["annotations"].(map[string]any)
+	value, _ := annotations[key].(string)
+	return value
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Label selector operators
+const (
+	SelectorOpIn           = "In"
+	SelectorOpNotIn        = "NotIn"
+	SelectorOpExists       = "Exists"
+	SelectorOpDoesNotExist = "DoesNotExist"
+)
+
+// LabelSelector selects resources by their labels, like the label selectors of the Kubernetes API.
+// All labels and expressions must match. A nil or empty selector matches every resource.
+type LabelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty" yaml:"matchLabels"`
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty" yaml:"matchExpressions"`
+}
+
+// LabelSelectorRequirement is an expression of a LabelSelector
+type LabelSelectorRequirement struct {
+	Key string `json:"key" yaml:"key"`
+	// Operator is one of: In, NotIn, Exists, DoesNotExist
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values"`
+}
+
+// Validate returns an error if an expression of the selector is invalid
+func (s *LabelSelector) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, r := range s.MatchExpressions {
+		switch {
+		case r.Key == "":
+			return fmt.Errorf("invalid label selector: expression without key")
+		case r.Operator == SelectorOpIn || r.Operator == SelectorOpNotIn:
+			if len(r.Values) == 0 {
+				return fmt.Errorf("invalid label selector: operator %s of key %s requires values", r.Operator, r.Key)
+			}
+		case r.Operator == SelectorOpExists || r.Operator == SelectorOpDoesNotExist:
+			if len(r.Values) > 0 {
+				return fmt.Errorf("invalid label selector: operator %s of key %s does not accept values", r.Operator, r.Key)
+			}
+		default:
+			return fmt.Errorf("invalid label selector: unsupported operator %q of key %s, expected one of: %s", r.Operator, r.Key,
+				strings.Join([]string{SelectorOpIn, SelectorOpNotIn, SelectorOpExists, SelectorOpDoesNotExist}, ", "))
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the labels match the selector
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil {
+		return true
+	}
+	for key, value := range s.MatchLabels {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	for _, r := range s.MatchExpressions {
+		value, ok := labels[r.Key]
+		switch r.Operator {
+		case SelectorOpIn:
+			if !ok || !containsString(r.Values, value) {
+				return false
+			}
+		case SelectorOpNotIn:
+			if ok && containsString(r.Values, value) {
+				return false
+			}
+		case SelectorOpExists:
+			if !ok {
+				return false
+			}
+		case SelectorOpDoesNotExist:
+			if ok {
+				return false
+			}
+		default:
+			// invalid operators are rejected by Validate
+			return false
+		}
+	}
+	return true
+}
+
+// Labels returns the labels of the parsed resource
+func (r Resource) Labels() map[string]string {
+	metadata, _ := r.Object["metadata"].(map[string]any)
+	values, _ := metadata["labels"].(map[string]any)
+	labels := make(map[string]string, len(values))
+	for key, value := range values {
+		labels[key] = fmt.Sprint(value)
+	}
+	return labels
+}
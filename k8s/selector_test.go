@@ -0,0 +1,36 @@
+package k8s
+
+import "testing"
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "frontend"}
+	tests := []struct {
+		name     string
+		selector *LabelSelector
+		want     bool
+	}{
+		{name: "nil", selector: nil, want: true},
+		{name: "match labels", selector: &LabelSelector{MatchLabels: map[string]string{"app": "web"}}, want: true},
+		{name: "match labels mismatch", selector: &LabelSelector{MatchLabels: map[string]string{"app": "api"}}, want: false},
+		{name: "in", selector: &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: SelectorOpIn, Values: []string{"frontend", "backend"}}}}, want: true},
+		{name: "not in", selector: &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: SelectorOpNotIn, Values: []string{"frontend"}}}}, want: false},
+		{name: "not in missing label", selector: &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "team", Operator: SelectorOpNotIn, Values: []string{"a"}}}}, want: true},
+		{name: "exists", selector: &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: SelectorOpExists}}}, want: true},
+		{name: "does not exist", selector: &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: SelectorOpDoesNotExist}}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.selector.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := tt.selector.Matches(labels); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	invalid := &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "Equals", Values: []string{"web"}}}}
+	if err := invalid.Validate(); err == nil {
+		t.Errorf("expected error for unsupported operator")
+	}
+}
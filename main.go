@@ -1,10 +1,17 @@
 package main
 
-import "github.com/redhat-consulting-services/kustomize-validator/commands"
+import (
+	"fmt"
+	"os"
+
+	"github.com/redhat-consulting-services/kustomize-validator/commands"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
 
 func main() {
 	err := commands.RootCmd.Execute()
 	if err != nil {
-		panic(err)
+		fmt.Fprint(os.Stderr, validate.Errorf("%s", err))
+		os.Exit(commands.ExitCode(err))
 	}
 }
@@ -0,0 +1,652 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// KindValidatingAdmissionPolicy is the kind of the evaluated policies
+	KindValidatingAdmissionPolicy = "ValidatingAdmissionPolicy"
+	// KindValidatingAdmissionPolicyBinding is the kind of the bindings enabling the policies
+	KindValidatingAdmissionPolicyBinding = "ValidatingAdmissionPolicyBinding"
+	// RuleAdmissionPolicy is the name of the admission policy check. Findings are identified by the name of their policy.
+	RuleAdmissionPolicy = "admission-policy"
+
+	admissionGroup = "admissionregistration.k8s.io"
+	// operationCreate is the operation of the simulated admission requests
+	operationCreate = "CREATE"
+	// namespaceNameLabel is set on every namespace by the API server
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// variables of the CEL expressions of admission policies
+const (
+	variableOldObject       = "oldObject"
+	variableRequest         = "request"
+	variableParams          = "params"
+	variableNamespaceObject = "namespaceObject"
+	variableVariables       = "variables"
+)
+
+// validatingAdmissionPolicy contains the fields of a ValidatingAdmissionPolicy evaluated offline
+type validatingAdmissionPolicy struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		ParamKind *struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+		} `json:"paramKind"`
+		MatchConstraints *matchResources  `json:"matchConstraints"`
+		Validations      []validation     `json:"validations"`
+		FailurePolicy    string           `json:"failurePolicy"`
+		MatchConditions  []namedCondition `json:"matchConditions"`
+		Variables        []namedCondition `json:"variables"`
+	} `json:"spec"`
+}
+
+// validatingAdmissionPolicyBinding contains the fields of a ValidatingAdmissionPolicyBinding evaluated offline
+type validatingAdmissionPolicyBinding struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		PolicyName        string          `json:"policyName"`
+		ParamRef          *paramRef       `json:"paramRef"`
+		MatchResources    *matchResources `json:"matchResources"`
+		ValidationActions []string        `json:"validationActions"`
+	} `json:"spec"`
+}
+
+type matchResources struct {
+	NamespaceSelector    *k8s.LabelSelector `json:"namespaceSelector"`
+	ObjectSelector       *k8s.LabelSelector `json:"objectSelector"`
+	ResourceRules        []resourceRule     `json:"resourceRules"`
+	ExcludeResourceRules []resourceRule     `json:"excludeResourceRules"`
+}
+
+type resourceRule struct {
+	ResourceNames []string `json:"resourceNames"`
+	Operations    []string `json:"operations"`
+	APIGroups     []string `json:"apiGroups"`
+	APIVersions   []string `json:"apiVersions"`
+	Resources     []string `json:"resources"`
+	Scope         string   `json:"scope"`
+}
+
+type validation struct {
+	Expression        string `json:"expression"`
+	Message           string `json:"message"`
+	MessageExpression string `json:"messageExpression"`
+	FieldPath         string `json:"fieldPath"`
+}
+
+type namedCondition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+type paramRef struct {
+	Name                    string             `json:"name"`
+	Namespace               string             `json:"namespace"`
+	Selector                *k8s.LabelSelector `json:"selector"`
+	ParameterNotFoundAction string             `json:"parameterNotFoundAction"`
+}
+
+// admissionPolicy is a compiled ValidatingAdmissionPolicy
+type admissionPolicy struct {
+	validatingAdmissionPolicy
+	// err is the compilation error of the policy, its failure policy applies to every matching resource
+	err             error
+	matchConditions []namedProgram
+	variables       []namedProgram
+	validations     []compiledValidation
+}
+
+type namedProgram struct {
+	name    string
+	program cel.Program
+}
+
+type compiledValidation struct {
+	validation
+	program cel.Program
+	// message is the program of the message expression, nil if there is none
+	message cel.Program
+}
+
+// Admission evaluates ValidatingAdmissionPolicies offline against rendered resources, as if
+// the resources were created. Only policies bound by a ValidatingAdmissionPolicyBinding are
+// evaluated. Violations are reported with the message of the policy and the severity of the
+// validation actions of the binding: Deny is an error, Warn a warning and Audit info.
+type Admission struct {
+	env      *cel.Env
+	policies map[string]*admissionPolicy
+	bindings map[string]validatingAdmissionPolicyBinding
+	// objects are all added resources, the candidates for the params of the policies
+	objects []k8s.Resource
+	// namespaces are the labels of the added namespaces by their name
+	namespaces map[string]k8s.Resource
+	// customKinds are the kinds of the added CustomResourceDefinitions by group and kind
+	customKinds map[string]customKind
+}
+
+// customKind is a kind defined by a CustomResourceDefinition
+type customKind struct {
+	// plural is the resource name of the kind
+	plural     string
+	namespaced bool
+}
+
+// clusterScopedKinds are the built-in kinds that are not namespaced, by group and kind.
+// All other built-in kinds are namespaced.
+var clusterScopedKinds = map[string]bool{
+	"/ComponentStatus":  true,
+	"/Namespace":        true,
+	"/Node":             true,
+	"/PersistentVolume": true,
+	"admissionregistration.k8s.io/MutatingAdmissionPolicy":          true,
+	"admissionregistration.k8s.io/MutatingAdmissionPolicyBinding":   true,
+	"admissionregistration.k8s.io/MutatingWebhookConfiguration":     true,
+	"admissionregistration.k8s.io/ValidatingAdmissionPolicy":        true,
+	"admissionregistration.k8s.io/ValidatingAdmissionPolicyBinding": true,
+	"admissionregistration.k8s.io/ValidatingWebhookConfiguration":   true,
+	"apiextensions.k8s.io/CustomResourceDefinition":                 true,
+	"apiregistration.k8s.io/APIService":                             true,
+	"certificates.k8s.io/CertificateSigningRequest":                 true,
+	"certificates.k8s.io/ClusterTrustBundle":                        true,
+	"flowcontrol.apiserver.k8s.io/FlowSchema":                       true,
+	"flowcontrol.apiserver.k8s.io/PriorityLevelConfiguration":       true,
+	"networking.k8s.io/IngressClass":                                true,
+	"networking.k8s.io/IPAddress":                                   true,
+	"networking.k8s.io/ServiceCIDR":                                 true,
+	"node.k8s.io/RuntimeClass":                                      true,
+	"rbac.authorization.k8s.io/ClusterRole":                         true,
+	"rbac.authorization.k8s.io/ClusterRoleBinding":                  true,
+	"resource.k8s.io/DeviceClass":                                   true,
+	"scheduling.k8s.io/PriorityClass":                               true,
+	"storage.k8s.io/CSIDriver":                                      true,
+	"storage.k8s.io/CSINode":                                        true,
+	"storage.k8s.io/StorageClass":                                   true,
+	"storage.k8s.io/VolumeAttachment":                               true,
+}
+
+// NewAdmission creates an admission policy evaluator without any policies
+func NewAdmission() (*Admission, error) {
+	env, err := newEnv(VariableObject, variableOldObject, variableRequest, variableParams, variableNamespaceObject, variableVariables)
+	if err != nil {
+		return nil, err
+	}
+	return &Admission{
+		env:         env,
+		policies:    map[string]*admissionPolicy{},
+		bindings:    map[string]validatingAdmissionPolicyBinding{},
+		namespaces:  map[string]k8s.Resource{},
+		customKinds: map[string]customKind{},
+	}, nil
+}
+
+// Add loads the policies, bindings, params, namespaces and CustomResourceDefinitions among the
+// resources, see AddResource. It stops at the first resource that cannot be loaded.
+func (a *Admission) Add(resources []k8s.Resource) error {
+	for _, resource := range resources {
+		if err := a.AddResource(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddResource loads the resource if it is a policy, binding, param, namespace or
+// CustomResourceDefinition. Policies and bindings replace the ones with the same name
+// added before. Policies whose expressions do not compile are loaded as well, their
+// failure policy applies.
+func (a *Admission) AddResource(resource k8s.Resource) error {
+	a.objects = append(a.objects, resource)
+	group, _ := splitAPIVersion(resource.ApiVersion)
+	switch {
+	case group == "" && resource.Kind == "Namespace":
+		a.namespaces[resource.Name] = resource
+	case group == "apiextensions.k8s.io" && resource.Kind == "CustomResourceDefinition":
+		crdGroup, _ := k8s.Lookup(resource.Object, "spec", "group").(string)
+		kind, _ := k8s.Lookup(resource.Object, "spec", "names", "kind").(string)
+		plural, _ := k8s.Lookup(resource.Object, "spec", "names", "plural").(string)
+		scope, _ := k8s.Lookup(resource.Object, "spec", "scope").(string)
+		a.customKinds[crdGroup+"/"+kind] = customKind{plural: plural, namespaced: scope != "Cluster"}
+	case group == admissionGroup && resource.Kind == KindValidatingAdmissionPolicy:
+		var policy validatingAdmissionPolicy
+		if err := convert(resource.Object, &policy); err != nil {
+			return fmt.Errorf("invalid %s %s: %w", resource.Kind, resource.Name, err)
+		}
+		a.policies[policy.Metadata.Name] = a.compile(policy)
+	case group == admissionGroup && resource.Kind == KindValidatingAdmissionPolicyBinding:
+		var binding validatingAdmissionPolicyBinding
+		if err := convert(resource.Object, &binding); err != nil {
+			return fmt.Errorf("invalid %s %s: %w", resource.Kind, resource.Name, err)
+		}
+		a.bindings[binding.Metadata.Name] = binding
+	}
+	return nil
+}
+
+// AddDir loads the policies, bindings and params of the YAML and JSON files in the directory and its subdirectories
+func (a *Admission) AddDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		var resources []k8s.Resource
+		dec := yaml.NewDecoder(file)
+		for {
+			var object map[string]any
+			err := dec.Decode(&object)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			resources = append(resources, objectResource(object, path))
+		}
+		if err := a.Add(resources); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// Policies returns the names of the loaded policies bound by at least one binding, sorted
+func (a *Admission) Policies() []string {
+	bound := map[string]bool{}
+	for _, binding := range a.bindings {
+		if _, ok := a.policies[binding.Spec.PolicyName]; ok {
+			bound[binding.Spec.PolicyName] = true
+		}
+	}
+	names := make([]string, 0, len(bound))
+	for name := range bound {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a *Admission) Name() string {
+	return RuleAdmissionPolicy
+}
+
+func (a *Admission) Check(_ validate.Kustomization, resource k8s.Resource) validate.Resources {
+	if resource.Object == nil {
+		return nil
+	}
+	names := make([]string, 0, len(a.bindings))
+	for name := range a.bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings validate.Resources
+	for _, name := range names {
+		binding := a.bindings[name]
+		policy, ok := a.policies[binding.Spec.PolicyName]
+		if !ok || policy.Spec.MatchConstraints == nil || !a.matches(policy.Spec.MatchConstraints, resource) {
+			continue
+		}
+		if binding.Spec.MatchResources != nil && !a.matches(binding.Spec.MatchResources, resource) {
+			continue
+		}
+		findings = append(findings, a.evaluate(policy, binding, resource)...)
+	}
+	return findings
+}
+
+// compile compiles the expressions of the policy
+func (a *Admission) compile(policy validatingAdmissionPolicy) *admissionPolicy {
+	compiled := &admissionPolicy{validatingAdmissionPolicy: policy}
+	var errs []error
+	for _, condition := range policy.Spec.MatchConditions {
+		program, err := compile(a.env, condition.Expression, cel.BoolType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("match condition %s: %w", condition.Name, err))
+		}
+		compiled.matchConditions = append(compiled.matchConditions, namedProgram{condition.Name, program})
+	}
+	for _, variable := range policy.Spec.Variables {
+		program, err := compile(a.env, variable.Expression, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("variable %s: %w", variable.Name, err))
+		}
+		compiled.variables = append(compiled.variables, namedProgram{variable.Name, program})
+	}
+	for i, v := range policy.Spec.Validations {
+		c := compiledValidation{validation: v}
+		var err error
+		if c.program, err = compile(a.env, v.Expression, cel.BoolType); err != nil {
+			errs = append(errs, fmt.Errorf("validation %d: %w", i+1, err))
+		}
+		if v.MessageExpression != "" {
+			if c.message, err = compile(a.env, v.MessageExpression, cel.StringType); err != nil {
+				errs = append(errs, fmt.Errorf("message expression of validation %d: %w", i+1, err))
+			}
+		}
+		compiled.validations = append(compiled.validations, c)
+	}
+	compiled.err = errors.Join(errs...)
+	return compiled
+}
+
+// evaluate evaluates the policy bound by the binding against the matching resource
+func (a *Admission) evaluate(policy *admissionPolicy, binding validatingAdmissionPolicyBinding, resource k8s.Resource) validate.Resources {
+	if policy.err != nil {
+		return a.failure(policy, binding, resource, policy.err)
+	}
+	if policy.Spec.ParamKind == nil {
+		return a.validate(policy, binding, resource, nil)
+	}
+	if binding.Spec.ParamRef == nil {
+		return a.failure(policy, binding, resource, errors.New("the policy has a paramKind, but the binding has no paramRef"))
+	}
+	params := a.params(policy, binding.Spec.ParamRef, resource)
+	if len(params) == 0 {
+		if binding.Spec.ParamRef.ParameterNotFoundAction == "Allow" {
+			return nil
+		}
+		return a.violation(policy, binding, resource, nil, "no params found for policy binding with `Deny` parameterNotFoundAction")
+	}
+	var findings validate.Resources
+	for _, param := range params {
+		findings = append(findings, a.validate(policy, binding, resource, param.Object)...)
+	}
+	return findings
+}
+
+// validate evaluates the match conditions, variables and validations of the policy with the given params
+func (a *Admission) validate(policy *admissionPolicy, binding validatingAdmissionPolicyBinding, resource k8s.Resource, params any) validate.Resources {
+	activation := map[string]any{
+		VariableObject:          resource.Object,
+		variableOldObject:       nil,
+		variableRequest:         a.request(resource),
+		variableParams:          params,
+		variableNamespaceObject: a.namespaceObject(resource),
+	}
+	for _, condition := range policy.matchConditions {
+		matches, err := evaluate(condition.program, activation)
+		if err != nil {
+			return a.failure(policy, binding, resource, fmt.Errorf("match condition %s: %w", condition.name, err))
+		}
+		if !matches {
+			return nil
+		}
+	}
+	// variables may reference the variables declared before them, failing variables
+	// fail the validations referencing them
+	variables := map[string]any{}
+	activation[variableVariables] = variables
+	for _, variable := range policy.variables {
+		if out, _, err := variable.program.Eval(activation); err == nil {
+			variables[variable.name] = out
+		}
+	}
+
+	var findings validate.Resources
+	for i, v := range policy.validations {
+		valid, err := evaluate(v.program, activation)
+		if err != nil {
+			findings = append(findings, a.failure(policy, binding, resource, fmt.Errorf("validation %d: %w", i+1, err))...)
+			continue
+		}
+		if valid {
+			continue
+		}
+		message := v.Message
+		if v.message != nil {
+			if out, _, err := v.message.Eval(activation); err == nil {
+				if msg, ok := out.Value().(string); ok && strings.TrimSpace(msg) != "" {
+					message = msg
+				}
+			}
+		}
+		if message == "" {
+			message = "failed expression: " + v.Expression
+		}
+		path, _ := k8s.ParseFieldPath(strings.TrimPrefix(v.FieldPath, "."))
+		findings = append(findings, a.violation(policy, binding, resource, path, message)...)
+	}
+	return findings
+}
+
+// failure reports the evaluation error of the policy according to its failure policy
+func (a *Admission) failure(policy *admissionPolicy, binding validatingAdmissionPolicyBinding, resource k8s.Resource, err error) validate.Resources {
+	if policy.Spec.FailurePolicy == "Ignore" {
+		return nil
+	}
+	return a.violation(policy, binding, resource, nil, fmt.Sprintf("failed to evaluate policy: %s", err))
+}
+
+// violation creates the finding of a policy violation with the severity of the validation actions of the binding
+func (a *Admission) violation(policy *admissionPolicy, binding validatingAdmissionPolicyBinding, resource k8s.Resource, path k8s.FieldPath, message string) validate.Resources {
+	finding := validate.NewFinding(resource, path, message)
+	finding.Rule = policy.Metadata.Name
+	finding.Description = fmt.Sprintf("%s %s bound by %s", KindValidatingAdmissionPolicy, policy.Metadata.Name, binding.Metadata.Name)
+	finding.Severity = validate.SeverityInfo
+	for _, action := range binding.Spec.ValidationActions {
+		switch action {
+		case "Deny":
+			finding.Severity = validate.SeverityError
+		case "Warn":
+			if !finding.Severity.AtLeast(validate.SeverityWarning) {
+				finding.Severity = validate.SeverityWarning
+			}
+		}
+	}
+	return validate.Resources{finding}
+}
+
+// matches reports whether the resource matches the resource rules and the selectors
+func (a *Admission) matches(m *matchResources, resource k8s.Resource) bool {
+	if len(m.ResourceRules) > 0 && !a.matchesAny(m.ResourceRules, resource) {
+		return false
+	}
+	if a.matchesAny(m.ExcludeResourceRules, resource) {
+		return false
+	}
+	if !m.ObjectSelector.Matches(resource.Labels()) {
+		return false
+	}
+	// the namespace selector does not apply to cluster-scoped resources other than namespaces
+	switch {
+	case resource.Kind == "Namespace" && resource.ApiVersion == "v1":
+		return m.NamespaceSelector.Matches(resource.Labels())
+	case a.namespaced(resource):
+		return m.NamespaceSelector.Matches(a.namespaceLabels(namespaceOf(resource)))
+	}
+	return true
+}
+
+// matchesAny reports whether any of the rules matches the creation of the resource
+func (a *Admission) matchesAny(rules []resourceRule, resource k8s.Resource) bool {
+	group, version := splitAPIVersion(resource.ApiVersion)
+	plural := a.resourceName(group, resource.Kind)
+	namespaced := a.namespaced(resource)
+	for _, rule := range rules {
+		switch {
+		case !matchesValue(rule.Operations, operationCreate),
+			!matchesValue(rule.APIGroups, group),
+			!matchesValue(rule.APIVersions, version),
+			!matchesValue(rule.Resources, plural) && !matchesValue(rule.Resources, "*/*"),
+			len(rule.ResourceNames) > 0 && !matchesValue(rule.ResourceNames, resource.Name),
+			rule.Scope == "Cluster" && namespaced,
+			rule.Scope == "Namespaced" && !namespaced:
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesValue reports whether the values of a resource rule contain the value or the "*" wildcard
+func matchesValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// params returns the param objects of the kind of the policy referenced by the binding
+func (a *Admission) params(policy *admissionPolicy, ref *paramRef, resource k8s.Resource) []k8s.Resource {
+	kind := policy.Spec.ParamKind
+	var params []k8s.Resource
+	for _, object := range a.objects {
+		if object.ApiVersion != kind.APIVersion || object.Kind != kind.Kind {
+			continue
+		}
+		// without namespace, namespaced params are taken from the namespace of the resource
+		namespace := namespaceOf(object)
+		if ref.Namespace != "" && namespace != ref.Namespace || ref.Namespace == "" && namespace != "" && namespace != namespaceOf(resource) {
+			continue
+		}
+		if ref.Name != "" && object.Name != ref.Name || ref.Name == "" && !ref.Selector.Matches(object.Labels()) {
+			continue
+		}
+		params = append(params, object)
+	}
+	return params
+}
+
+// request returns the simulated admission request of the creation of the resource
+func (a *Admission) request(resource k8s.Resource) map[string]any {
+	group, version := splitAPIVersion(resource.ApiVersion)
+	return map[string]any{
+		"kind":      map[string]any{"group": group, "version": version, "kind": resource.Kind},
+		"resource":  map[string]any{"group": group, "version": version, "resource": a.resourceName(group, resource.Kind)},
+		"name":      resource.Name,
+		"namespace": namespaceOf(resource),
+		"operation": operationCreate,
+		"userInfo":  map[string]any{},
+		"dryRun":    false,
+	}
+}
+
+// namespaceObject returns the namespace of the resource, nil for cluster-scoped resources.
+// Namespaces not found among the added resources only have a name and the name label,
+// the unknown namespace of namespaced resources without namespace has neither.
+func (a *Admission) namespaceObject(resource k8s.Resource) any {
+	if !a.namespaced(resource) {
+		return nil
+	}
+	namespace := namespaceOf(resource)
+	if ns, ok := a.namespaces[namespace]; ok && namespace != "" {
+		return ns.Object
+	}
+	metadata := map[string]any{}
+	if namespace != "" {
+		metadata = map[string]any{"name": namespace, "labels": map[string]any{namespaceNameLabel: namespace}}
+	}
+	return map[string]any{"apiVersion": "v1", "kind": "Namespace", "metadata": metadata}
+}
+
+// namespaceLabels returns the labels of the namespace, including the name label set by the API server.
+// The unknown namespace of namespaced resources without namespace, the empty name, has no labels.
+func (a *Admission) namespaceLabels(namespace string) map[string]string {
+	labels := map[string]string{}
+	if namespace == "" {
+		return labels
+	}
+	if ns, ok := a.namespaces[namespace]; ok {
+		labels = ns.Labels()
+	}
+	labels[namespaceNameLabel] = namespace
+	return labels
+}
+
+// namespaced reports whether the kind of the resource is namespaced. The scope of custom
+// resources is taken from their CustomResourceDefinition, kinds not known to be cluster-scoped
+// are namespaced. Namespaced resources without namespace are deployed to a namespace unknown
+// when rendering, e.g., the destination namespace of an Argo CD Application.
+func (a *Admission) namespaced(resource k8s.Resource) bool {
+	group, _ := splitAPIVersion(resource.ApiVersion)
+	if kind, ok := a.customKinds[group+"/"+resource.Kind]; ok {
+		return kind.namespaced
+	}
+	return !clusterScopedKinds[group+"/"+resource.Kind]
+}
+
+// resourceName returns the resource name of the kind, e.g., deployments for Deployment.
+// The names of custom resources are taken from their CustomResourceDefinition, all
+// other names are derived from the kind.
+func (a *Admission) resourceName(group, kind string) string {
+	if kind, ok := a.customKinds[group+"/"+kind]; ok {
+		return kind.plural
+	}
+	name := strings.ToLower(kind)
+	switch {
+	case name == "endpoints":
+		return name
+	case len(name) > 1 && strings.HasSuffix(name, "y") && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// splitAPIVersion splits the apiVersion into group and version, the group of the core API is empty
+func splitAPIVersion(apiVersion string) (string, string) {
+	group, version, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		return "", apiVersion
+	}
+	return group, version
+}
+
+// namespaceOf returns the namespace of the parsed resource, empty if it has none
+func namespaceOf(resource k8s.Resource) string {
+	namespace, _ := k8s.Lookup(resource.Object, "metadata", "namespace").(string)
+	return namespace
+}
+
+// objectResource creates a resource of a parsed object loaded from the file
+func objectResource(object map[string]any, file string) k8s.Resource {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	name, _ := k8s.Lookup(object, "metadata", "name").(string)
+	namespace, _ := k8s.Lookup(object, "metadata", "namespace").(string)
+	return k8s.Resource{ApiVersion: apiVersion, Kind: kind, Name: name, Namespace: namespace, SourcePath: file, Object: object}
+}
+
+// convert converts the parsed object into the typed value
+func convert(object map[string]any, v any) error {
+	content, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
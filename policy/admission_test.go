@@ -0,0 +1,400 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+const admissionResourcesExample = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop-prod
+  labels:
+    environment: prod
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop-prod
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop-prod
+spec:
+  replicas: 3
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: shop-dev
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: orphan
+spec:
+  replicas: 1
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shop-prod
+data:
+  minReplicas: "2"
+`
+
+const replicasPolicy = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: min-replicas
+spec:
+  failurePolicy: Fail
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["apps"]
+        apiVersions: ["v1"]
+        operations: ["CREATE", "UPDATE"]
+        resources: ["deployments"]
+  validations:
+    - expression: object.spec.replicas >= 2
+      message: Deployments must run at least two replicas
+      fieldPath: .spec.replicas
+`
+
+const replicasBinding = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: min-replicas-prod
+spec:
+  policyName: min-replicas
+  validationActions: [Deny]
+  matchResources:
+    namespaceSelector:
+      matchLabels:
+        environment: prod
+`
+
+func TestAdmission_Check(t *testing.T) {
+	tests := []struct {
+		name         string
+		policies     string
+		want         []string
+		wantSeverity validate.Severity
+	}{
+		{
+			name:     "unbound policy",
+			policies: replicasPolicy,
+		},
+		{
+			name:         "namespace selector",
+			policies:     replicasPolicy + "---\n" + replicasBinding,
+			want:         []string{"api spec.replicas: Deployments must run at least two replicas"},
+			wantSeverity: validate.SeverityError,
+		},
+		{
+			name: "object selector and warn action",
+			policies: replicasPolicy + `---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: min-replicas-backend
+spec:
+  policyName: min-replicas
+  validationActions: [Warn, Audit]
+  matchResources:
+    objectSelector:
+      matchLabels:
+        tier: backend
+`,
+			want:         []string{"api spec.replicas: Deployments must run at least two replicas", "worker spec.replicas: Deployments must run at least two replicas"},
+			wantSeverity: validate.SeverityWarning,
+		},
+		{
+			name: "message expression, variables and match conditions",
+			policies: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: min-replicas
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["*"]
+        apiVersions: ["*"]
+        operations: ["*"]
+        resources: ["*"]
+    excludeResourceRules:
+      - apiGroups: [""]
+        apiVersions: ["v1"]
+        operations: ["*"]
+        resources: ["namespaces"]
+  matchConditions:
+    - name: has-replicas
+      expression: has(object.spec) && has(object.spec.replicas)
+  variables:
+    - name: environment
+      expression: namespaceObject.metadata.?labels.?environment.orValue('dev')
+  validations:
+    - expression: variables.environment != 'prod' || object.spec.replicas >= 2
+      messageExpression: "object.metadata.name + ' runs ' + string(object.spec.replicas) + ' replica(s) in ' + request.namespace"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: min-replicas
+spec:
+  policyName: min-replicas
+  validationActions: [Deny]
+`,
+			want:         []string{"api: api runs 1 replica(s) in shop-prod"},
+			wantSeverity: validate.SeverityError,
+		},
+		{
+			name: "params",
+			policies: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: min-replicas
+spec:
+  paramKind:
+    apiVersion: v1
+    kind: ConfigMap
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["apps"]
+        apiVersions: ["v1"]
+        operations: ["CREATE"]
+        resources: ["deployments"]
+  validations:
+    - expression: object.spec.replicas >= int(params.data.minReplicas)
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: min-replicas
+spec:
+  policyName: min-replicas
+  validationActions: [Audit]
+  paramRef:
+    name: settings
+    parameterNotFoundAction: Deny
+`,
+			want: []string{
+				"api: failed expression: object.spec.replicas >= int(params.data.minReplicas)",
+				"worker: no params found for policy binding with `Deny` parameterNotFoundAction",
+				"orphan: no params found for policy binding with `Deny` parameterNotFoundAction",
+			},
+			wantSeverity: validate.SeverityInfo,
+		},
+		{
+			name: "scope",
+			policies: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: labeled
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["*"]
+        apiVersions: ["*"]
+        operations: ["CREATE"]
+        resources: ["deployments", "clusterroles", "namespaces"]
+        scope: Namespaced
+  validations:
+    - expression: has(object.metadata.annotations)
+      message: namespaced resources must be annotated
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: labeled
+spec:
+  policyName: labeled
+  validationActions: [Deny]
+  matchResources:
+    namespaceSelector:
+      matchExpressions:
+        - key: environment
+          operator: NotIn
+          values: [prod]
+`,
+			want:         []string{"worker: namespaced resources must be annotated", "orphan: namespaced resources must be annotated"},
+			wantSeverity: validate.SeverityError,
+		},
+		{
+			name: "update only",
+			policies: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: immutable
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["apps"]
+        apiVersions: ["v1"]
+        operations: ["UPDATE"]
+        resources: ["deployments"]
+  validations:
+    - expression: "false"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: immutable
+spec:
+  policyName: immutable
+  validationActions: [Deny]
+`,
+		},
+		{
+			name: "invalid expression with ignore failure policy",
+			policies: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: invalid
+spec:
+  failurePolicy: Ignore
+  matchConstraints:
+    resourceRules:
+      - apiGroups: ["apps"]
+        apiVersions: ["v1"]
+        operations: ["CREATE"]
+        resources: ["deployments"]
+  validations:
+    - expression: object.spec.replicas >=
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: invalid
+spec:
+  policyName: invalid
+  validationActions: [Deny]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admission, err := NewAdmission()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resources := k8s.ParseKustomizeOutput(admissionResourcesExample, "example", "", "")
+			for _, rendered := range [][]k8s.Resource{resources, k8s.ParseKustomizeOutput(tt.policies, "policies", "", "")} {
+				if err := admission.Add(rendered); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			var got []string
+			for _, finding := range validate.Run(validate.Kustomization{Resources: resources}, []validate.Check{admission}) {
+				if finding.Severity != tt.wantSeverity {
+					t.Errorf("expected severity %s, got: %s", tt.wantSeverity, finding.Severity)
+				}
+				name := finding.Name
+				if finding.FieldPath != "" {
+					name += " " + finding.FieldPath
+				}
+				got = append(got, name+": "+finding.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected findings %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAdmission_AddDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte(replicasPolicy+"---\n"+replicasBinding), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# policies"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	admission, err := NewAdmission()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := admission.AddDir(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := admission.Policies(); !reflect.DeepEqual(got, []string{"min-replicas"}) {
+		t.Errorf("expected bound policy min-replicas, got: %v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("kind: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := admission.AddDir(dir); err == nil {
+		t.Errorf("expected error for invalid YAML")
+	}
+}
+
+func TestAdmission_resourceName(t *testing.T) {
+	admission, err := NewAdmission()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	crd := k8s.ParseKustomizeOutput(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: mice.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Mouse
+    plural: mice
+`, "crds", "", "")
+	if err := admission.Add(crd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if admission.namespaced(k8s.Resource{ApiVersion: "example.com/v1", Kind: "Mouse"}) {
+		t.Errorf("expected cluster-scoped custom resource")
+	}
+	if !admission.namespaced(k8s.Resource{ApiVersion: "apps/v1", Kind: "Deployment"}) {
+		t.Errorf("expected namespaced Deployment without namespace")
+	}
+	tests := []struct {
+		group string
+		kind  string
+		want  string
+	}{
+		{group: "apps", kind: "Deployment", want: "deployments"},
+		{group: "networking.k8s.io", kind: "NetworkPolicy", want: "networkpolicies"},
+		{group: "networking.k8s.io", kind: "Ingress", want: "ingresses"},
+		{kind: "Endpoints", want: "endpoints"},
+		{kind: "Pod", want: "pods"},
+		{group: "example.com", kind: "Mouse", want: "mice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := admission.resourceName(tt.group, tt.kind); got != tt.want {
+				t.Errorf("expected %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
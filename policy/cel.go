@@ -0,0 +1,154 @@
+// Package policy evaluates policies written as CEL expressions against rendered resources.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// VariableObject is the CEL variable of the evaluated resource
+const VariableObject = "object"
+
+// CELRule is a rule written as CEL expression, it is violated if the expression evaluates to false
+type CELRule struct {
+	// Rule contains the ID, severity, description and remediation of the rule, its check is unused
+	validate.Rule
+	// Expression is evaluated with the resource bound to the variable object
+	Expression string
+	// Message is a text/template rendered with the fields of the resource, e.g.,
+	// "{{ .metadata.name }} runs {{ .spec.replicas }} replica(s)"
+	Message string
+	// FieldPath is the field the findings are reported at, empty for the resource as a whole
+	FieldPath k8s.FieldPath
+	// Kinds are the kinds of the resources the rule applies to, matched literally, all kinds if empty
+	Kinds []string
+	// Selector selects the resources the rule applies to by their labels, all resources if nil
+	Selector *k8s.LabelSelector
+}
+
+// CELCheck is the Check of a CEL rule
+type CELCheck struct {
+	rule    CELRule
+	program cel.Program
+	message *template.Template
+}
+
+// newEnv creates the CEL environment of the expressions, with the standard extensions
+// for strings, lists and sets and support for optional fields, e.g., object.?spec.replicas
+func newEnv(variables ...string) (*cel.Env, error) {
+	opts := []cel.EnvOption{ext.Strings(), ext.Lists(), ext.Sets(), cel.OptionalTypes()}
+	for _, variable := range variables {
+		opts = append(opts, cel.Variable(variable, cel.DynType))
+	}
+	return cel.NewEnv(opts...)
+}
+
+// compile compiles the CEL expression in the environment. Unless the output type is nil,
+// the expression must evaluate to it.
+func compile(env *cel.Env, expression string, outputType *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if outputType != nil && !ast.OutputType().IsExactType(outputType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression must evaluate to %s, got %s", outputType, ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// NewCELCheck compiles the expression and the message template of the rule
+func NewCELCheck(rule CELRule) (*CELCheck, error) {
+	if err := rule.Selector.Validate(); err != nil {
+		return nil, err
+	}
+	env, err := newEnv(VariableObject)
+	if err != nil {
+		return nil, err
+	}
+	program, err := compile(env, rule.Expression, cel.BoolType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	message := rule.Message
+	if message == "" {
+		message = "failed expression: " + rule.Expression
+	}
+	tmpl, err := template.New(rule.ID).Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &CELCheck{rule: rule, program: program, message: tmpl}, nil
+}
+
+func (c *CELCheck) Name() string {
+	return c.rule.ID
+}
+
+func (c *CELCheck) Check(_ validate.Kustomization, resource k8s.Resource) validate.Resources {
+	if resource.Object == nil || !c.matches(resource) {
+		return nil
+	}
+	var message string
+	valid, err := evaluate(c.program, map[string]any{VariableObject: resource.Object})
+	switch {
+	case err != nil:
+		message = fmt.Sprintf("failed to evaluate expression: %s", err)
+	case valid:
+		return nil
+	default:
+		var b strings.Builder
+		if err := c.message.Execute(&b, resource.Object); err != nil {
+			message = fmt.Sprintf("failed to render message: %s", err)
+		} else {
+			message = b.String()
+		}
+	}
+
+	finding := validate.NewFinding(resource, c.rule.FieldPath, message)
+	finding.Rule = c.rule.ID
+	finding.Description = c.rule.Description
+	finding.Severity = c.rule.Severity
+	finding.Remediation = c.rule.Remediation
+	return validate.Resources{finding}
+}
+
+// matches reports whether the rule applies to the resource
+func (c *CELCheck) matches(resource k8s.Resource) bool {
+	if !matchesKind(c.rule.Kinds, resource.Kind) {
+		return false
+	}
+	return c.rule.Selector.Matches(resource.Labels())
+}
+
+// evaluate evaluates the boolean program with the given variables
+func evaluate(program cel.Program, variables map[string]any) (bool, error) {
+	out, _, err := program.Eval(variables)
+	if err != nil {
+		return false, err
+	}
+	valid, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v instead of bool", out.Value())
+	}
+	return valid, nil
+}
+
+// matchesKind reports whether the kind is one of the kinds, any kind matches no kinds.
+// Kinds are matched literally, there is no wildcard.
+func matchesKind(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+const deploymentsExample = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop-prod
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop-prod
+  labels:
+    tier: frontend
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: shop-dev
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: shop-prod
+  labels:
+    tier: backend
+spec:
+  replicas: "two"
+`
+
+func TestCELCheck_Check(t *testing.T) {
+	resources := k8s.ParseKustomizeOutput(deploymentsExample, "example", "", "")
+	rule := CELRule{
+		Rule:       validate.Rule{ID: "prod-replicas", Description: "Production workloads must run at least two replicas", Severity: validate.SeverityWarning},
+		Expression: "!object.metadata.namespace.endsWith('-prod') || object.spec.replicas >= 2",
+		Message:    "{{ .metadata.name }} runs {{ .spec.replicas }} replica(s)",
+		FieldPath:  k8s.FieldPath{"spec", "replicas"},
+		Kinds:      []string{"Deployment", "StatefulSet"},
+		Selector:   &k8s.LabelSelector{MatchLabels: map[string]string{"tier": "backend"}},
+	}
+	check, err := NewCELCheck(rule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, finding := range validate.Run(validate.Kustomization{Resources: resources}, []validate.Check{check}) {
+		if finding.Rule != "prod-replicas" || finding.Severity != validate.SeverityWarning || finding.FieldPath != "spec.replicas" {
+			t.Errorf("expected finding of the rule at spec.replicas, got: %+v", finding)
+		}
+		got = append(got, finding.Name+": "+finding.Message)
+	}
+	want := []string{
+		"api: api runs 1 replica(s)",
+		"db: failed to evaluate expression: no such overload",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected findings %v, got: %v", want, got)
+	}
+}
+
+func Test_matchesKind(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds []string
+		kind  string
+		want  bool
+	}{
+		{name: "no kinds", kind: "Deployment", want: true},
+		{name: "listed kind", kinds: []string{"StatefulSet", "Deployment"}, kind: "Deployment", want: true},
+		{name: "unlisted kind", kinds: []string{"StatefulSet"}, kind: "Deployment", want: false},
+		{name: "no wildcard", kinds: []string{"*"}, kind: "Deployment", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesKind(tt.kinds, tt.kind); got != tt.want {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewCELCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CELRule
+		wantErr bool
+	}{
+		{name: "valid", rule: CELRule{Expression: "object.spec.replicas >= 2"}},
+		{name: "optional field", rule: CELRule{Expression: "object.?spec.replicas.orValue(1) >= 2"}},
+		{name: "syntax error", rule: CELRule{Expression: "object.spec.replicas >="}, wantErr: true},
+		{name: "not bool", rule: CELRule{Expression: "'replicas'"}, wantErr: true},
+		{name: "undeclared variable", rule: CELRule{Expression: "request.userInfo.username == 'admin'"}, wantErr: true},
+		{name: "invalid message", rule: CELRule{Expression: "true", Message: "{{ .metadata.name"}, wantErr: true},
+		{name: "invalid selector", rule: CELRule{Expression: "true", Selector: &k8s.LabelSelector{MatchExpressions: []k8s.LabelSelectorRequirement{{Key: "tier", Operator: "In"}}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCELCheck(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
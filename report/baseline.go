@@ -0,0 +1,216 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// BaselineFileName is the default name of the baseline file
+const BaselineFileName = ".kustomize-validator-baseline.yaml"
+
+// baselineHeader is written at the top of every baseline file
+const baselineHeader = "# Known findings of kustomize-validator, regenerate with: kustomize-validator baseline update\n"
+
+// Baseline contains the known findings of a repository. Findings recorded in the
+// baseline do not fail a validation run, so stricter checks can be adopted before
+// all existing findings are fixed.
+type Baseline struct {
+	Entries []BaselineEntry `yaml:"entries"`
+
+	// dir is the directory of the baseline file, all paths are relative to it
+	dir string
+	// known counts the recorded findings per entry without count
+	known map[BaselineEntry]int
+	// matched counts the findings matched per entry without count
+	matched map[BaselineEntry]int
+	// validated contains the paths of all kustomizations filtered by the baseline
+	validated map[string]bool
+}
+
+// BaselineEntry identifies known findings by the kustomization, the resource and the rule
+type BaselineEntry struct {
+	// Path of the kustomization relative to the baseline file
+	Path       string `yaml:"path" json:"path"`
+	ApiVersion string `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string `yaml:"kind" json:"kind"`
+	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Name       string `yaml:"name" json:"name"`
+	Rule       string `yaml:"rule" json:"rule"`
+	// Count is the number of known findings, stale entries contain the number of findings no longer found
+	Count int `yaml:"count" json:"count"`
+}
+
+// LoadBaseline reads the baseline file at the given path
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Baseline{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(b); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := b.init(path); err != nil {
+		return nil, err
+	}
+	for _, entry := range b.Entries {
+		key := entry
+		key.Count = 0
+		b.known[key] += entry.Count
+	}
+	return b, nil
+}
+
+// NewBaseline creates the baseline file at the given path from the findings of the report
+func NewBaseline(path string, r *Report) (*Baseline, error) {
+	b := &Baseline{}
+	if err := b.init(path); err != nil {
+		return nil, err
+	}
+
+	counts := map[BaselineEntry]int{}
+	for _, k := range r.Kustomizations {
+		for _, f := range k.Findings {
+			counts[b.key(k.Path, f.resource())]++
+		}
+	}
+	for entry, count := range counts {
+		entry.Count = count
+		b.Entries = append(b.Entries, entry)
+	}
+	sortBaselineEntries(b.Entries)
+	return b, nil
+}
+
+func (b *Baseline) init(path string) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	b.dir = dir
+	b.known = map[BaselineEntry]int{}
+	b.matched = map[BaselineEntry]int{}
+	b.validated = map[string]bool{}
+	return nil
+}
+
+// Write writes the baseline to the given file
+func (b *Baseline) Write(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString(baselineHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	entries := b.Entries
+	if entries == nil {
+		entries = []BaselineEntry{}
+	}
+	if err := enc.Encode(struct {
+		Entries []BaselineEntry `yaml:"entries"`
+	}{entries}); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Filter separates the findings of the kustomization at the given path that are
+// recorded in the baseline from the new findings. Every entry matches at most as
+// many findings as it records.
+func (b *Baseline) Filter(path string, findings validate.Resources) (remaining validate.Resources, baselined validate.Resources) {
+	b.validated[b.relative(path)] = true
+	for _, finding := range findings {
+		key := b.key(path, finding)
+		if b.matched[key] < b.known[key] {
+			b.matched[key]++
+			baselined = append(baselined, finding)
+			continue
+		}
+		remaining = append(remaining, finding)
+	}
+	return remaining, baselined
+}
+
+// Stale returns the entries of the filtered kustomizations recording more findings
+// than were found, with the number of findings no longer found as their count. If
+// removed is set, the entries of kustomizations that no longer exist, e.g., deleted or
+// renamed overlays, are stale as well; set it only if all kustomizations were validated.
+func (b *Baseline) Stale(removed bool) []BaselineEntry {
+	var stale []BaselineEntry
+	for key, count := range b.known {
+		switch {
+		case b.validated[key.Path]:
+		case removed && validate.KustomizationFile(filepath.Join(b.dir, filepath.FromSlash(key.Path))) == "":
+		default:
+			continue
+		}
+		if b.matched[key] >= count {
+			continue
+		}
+		key.Count = count - b.matched[key]
+		stale = append(stale, key)
+	}
+	sortBaselineEntries(stale)
+	return stale
+}
+
+// key returns the entry of the finding without count
+func (b *Baseline) key(path string, finding validate.Resource) BaselineEntry {
+	return BaselineEntry{
+		Path:       b.relative(path),
+		ApiVersion: finding.ApiVersion,
+		Kind:       finding.Kind,
+		Namespace:  finding.Namespace,
+		Name:       finding.Name,
+		Rule:       finding.Rule,
+	}
+}
+
+// relative returns the path relative to the directory of the baseline file
+func (b *Baseline) relative(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(b.dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// String formats the entry for display
+func (e BaselineEntry) String() string {
+	return fmt.Sprintf("%s: %d finding(s) of rule %s in resource %s/%s/%s/%s", e.Path, e.Count, e.Rule, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+}
+
+func sortBaselineEntries(entries []BaselineEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.ApiVersion != b.ApiVersion {
+			return a.ApiVersion < b.ApiVersion
+		}
+		return a.Rule < b.Rule
+	})
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, BaselineFileName)
+	deployment := k8s.Resource{ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "<none>", Name: "my-app"}
+	service := k8s.Resource{ApiVersion: "v1", Kind: "Service", Namespace: "<none>", Name: "my-app"}
+	placeholder := func(resource k8s.Resource, line int) validate.Resource {
+		return validate.Resource{Resource: resource, Rule: "PATCH_ME", Pattern: "PATCH_ME", LineNumber: line}
+	}
+
+	r := New(dir)
+	r.Add(NewKustomization(validate.Carrier{Path: filepath.Join(dir, "overlays", "prod")}, nil, validate.Resources{
+		placeholder(deployment, 10),
+		placeholder(deployment, 20),
+		placeholder(service, 5),
+	}))
+	baseline, err := NewBaseline(file, r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := baseline.Write(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	baseline, err = LoadBaseline(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []BaselineEntry{
+		{Path: "overlays/prod", ApiVersion: "apps/v1", Kind: "Deployment", Namespace: "<none>", Name: "my-app", Rule: "PATCH_ME", Count: 2},
+		{Path: "overlays/prod", ApiVersion: "v1", Kind: "Service", Namespace: "<none>", Name: "my-app", Rule: "PATCH_ME", Count: 1},
+	}
+	if !reflect.DeepEqual(baseline.Entries, want) {
+		t.Fatalf("expected entries %+v, got: %+v", want, baseline.Entries)
+	}
+
+	// a third placeholder in the deployment is new, the one in the service was fixed
+	remaining, baselined := baseline.Filter(filepath.Join(dir, "overlays", "prod"), validate.Resources{
+		placeholder(deployment, 10),
+		placeholder(deployment, 20),
+		placeholder(deployment, 30),
+		{Resource: deployment, Rule: "TODO", Pattern: "TODO", LineNumber: 40},
+	})
+	if len(baselined) != 2 {
+		t.Errorf("expected 2 baselined findings, got: %d", len(baselined))
+	}
+	var lines []int
+	for _, finding := range remaining {
+		lines = append(lines, finding.LineNumber)
+	}
+	if !reflect.DeepEqual(lines, []int{30, 40}) {
+		t.Errorf("expected new findings in lines [30 40], got: %v", lines)
+	}
+
+	stale := baseline.Stale(false)
+	if len(stale) != 1 || stale[0].Kind != "Service" || stale[0].Count != 1 {
+		t.Errorf("expected the service entry to be stale, got: %+v", stale)
+	}
+}
+
+func TestBaseline_StaleRemoved(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, BaselineFileName)
+	if err := os.MkdirAll(filepath.Join(dir, "overlays", "legacy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// overlays/legacy was excluded from the run, overlays/dev was deleted
+	if err := os.WriteFile(filepath.Join(dir, "overlays", "legacy", "kustomization.yaml"), []byte("resources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	content := `entries:
+  - {path: overlays/legacy, apiVersion: v1, kind: Service, name: my-app, rule: PATCH_ME, count: 1}
+  - {path: overlays/dev, apiVersion: v1, kind: Service, name: my-app, rule: PATCH_ME, count: 2}
+`
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaseline(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		removed bool
+		want    []string
+	}{
+		{name: "partial run", want: nil},
+		{name: "full run", removed: true, want: []string{"overlays/dev: 2 finding(s) of rule PATCH_ME in resource v1/Service//my-app"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, entry := range baseline.Stale(tt.removed) {
+				got = append(got, entry.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected stale entries %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadBaseline_unknownField(t *testing.T) {
+	file := filepath.Join(t.TempDir(), BaselineFileName)
+	if err := os.WriteFile(file, []byte("entires: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(file); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+	SystemErr *junitOutput  `xml:"system-err,omitempty"`
+}
+
+type junitOutput struct {
+	Body string `xml:",cdata"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// WriteJUnit writes the report as JUnit XML. Every kustomization is a test suite
+// containing a test case for its build and one for the content checks of each
+// rendered resource.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitTestSuites{Name: "kustomize-validator"}
+	for _, k := range r.Kustomizations {
+		suite := junitTestSuite{Name: k.Path}
+
+		build := junitTestCase{Name: "kustomize build", ClassName: k.Path}
+		if k.Stderr != "" {
+			build.SystemErr = &junitOutput{Body: k.Stderr}
+		}
+		switch k.Status {
+		case StatusTimeout:
+			build.Failure = &junitFailure{Message: "kustomize build timed out", Type: string(StatusTimeout), Body: k.Error}
+		case StatusBuildFailed:
+			build.Failure = &junitFailure{Message: "kustomize build failed", Type: string(StatusBuildFailed), Body: k.Error}
+		}
+		suite.TestCases = append(suite.TestCases, build)
+
+		for _, resource := range k.Resources {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s/%s/%s/%s", resource.ApiVersion, resource.Kind, resource.Namespace, resource.Name),
+				ClassName: k.Path,
+			}
+
+			// findings below error severity do not fail the test case
+			var body, out strings.Builder
+			count := 0
+			for _, f := range k.Findings {
+				if !f.of(resource) {
+					continue
+				}
+				rsc := f.resource()
+				if !f.Severity.AtLeast(validate.SeverityError) {
+					out.WriteString(validate.StripColor(rsc.FormatError(true)))
+					continue
+				}
+				body.WriteString(validate.StripColor(rsc.FormatError(true)))
+				count++
+			}
+			for _, f := range k.Suppressed {
+				if !f.of(resource) {
+					continue
+				}
+				rsc := f.resource()
+				out.WriteString("Suppressed by the " + validate.IgnoreAnnotation + " annotation:\n")
+				out.WriteString(validate.StripColor(rsc.FormatError(true)))
+			}
+			for _, f := range k.Baselined {
+				if !f.of(resource) {
+					continue
+				}
+				rsc := f.resource()
+				out.WriteString("Recorded in the baseline:\n")
+				out.WriteString(validate.StripColor(rsc.FormatError(true)))
+			}
+			if out.Len() > 0 {
+				tc.SystemOut = &junitOutput{Body: out.String()}
+			}
+			if count > 0 {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("content validation failed with %d finding(s)", count),
+					Type:    string(StatusValidationFailed),
+					Body:    body.String(),
+				}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		for _, tc := range suite.TestCases {
+			suite.Tests++
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	pod := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "my-app", Namespace: "default"}
+	svc := k8s.Resource{ApiVersion: "v1", Kind: "Service", Name: "my-app", Namespace: "default"}
+	r := New("./apps")
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/a", Err: errors.New("exit status 1")}, nil, nil))
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/b"}, []k8s.Resource{pod, svc}, validate.Resources{
+		{Resource: pod, Pattern: "PATCH_ME", LineNumber: 6, MatchedLine: "    app: PATCH_ME"},
+	}))
+
+	buf := &bytes.Buffer{}
+	if err := WriteJUnit(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if got.Tests != 4 || got.Failures != 2 {
+		t.Errorf("expected 4 tests with 2 failures, got: %d tests with %d failures", got.Tests, got.Failures)
+	}
+	if len(got.Suites) != 2 {
+		t.Fatalf("expected 2 test suites, got: %d", len(got.Suites))
+	}
+
+	failure := got.Suites[1].TestCases[1].Failure
+	if failure == nil {
+		t.Fatalf("expected failure for %s", got.Suites[1].TestCases[1].Name)
+	}
+	if !strings.Contains(failure.Body, "Pattern: PATCH_ME") || strings.Contains(failure.Body, "\033") {
+		t.Errorf("expected uncolored FormatError output, got: %q", failure.Body)
+	}
+	if got.Suites[1].TestCases[2].Failure != nil {
+		t.Errorf("expected no failure for resource without findings")
+	}
+}
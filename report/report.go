@@ -0,0 +1,236 @@
+// Package report provides a structured representation of a validation run
+// and writers rendering it in machine-readable formats.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// Status is the outcome of validating a single kustomization
+type Status string
+
+const (
+	StatusSuccess          Status = "success"
+	StatusBuildFailed      Status = "build-failed"
+	StatusValidationFailed Status = "validation-failed"
+	StatusTimeout          Status = "timeout"
+)
+
+// Report contains the results of a complete validation run
+type Report struct {
+	// Path the validation was started from
+	Path           string          `json:"path"`
+	Kustomizations []Kustomization `json:"kustomizations"`
+	Summary        Summary         `json:"summary"`
+	// Baseline is the baseline file of known findings, empty if none is used
+	Baseline string `json:"baseline,omitempty"`
+	// StaleBaseline are the baseline entries of findings no longer found
+	StaleBaseline []BaselineEntry `json:"staleBaseline,omitempty"`
+	// Skipped are the directories of the kustomizations excluded from discovery
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Summary contains the counters of a validation run
+type Summary struct {
+	Total   int `json:"total"`
+	Success int `json:"success"`
+	Error   int `json:"error"`
+	Timeout int `json:"timeout"`
+	// Findings is the number of findings of all kustomizations
+	Findings int `json:"findings"`
+	// Suppressed is the number of findings suppressed by resource annotations
+	Suppressed int `json:"suppressed"`
+	// Baselined is the number of findings recorded in the baseline
+	Baselined int `json:"baselined"`
+	// Skipped is the number of kustomizations excluded from discovery
+	Skipped int `json:"skipped"`
+}
+
+// Kustomization is the result of building and validating a single kustomization
+type Kustomization struct {
+	Path      string         `json:"path"`
+	File      string         `json:"file"`
+	Status    Status         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Stderr    string         `json:"stderr"`
+	Resources []k8s.Resource `json:"resources"`
+	Findings  []Finding      `json:"findings"`
+	// Suppressed are the findings suppressed by resource annotations, listed for auditing
+	Suppressed []Finding `json:"suppressed"`
+	// Baselined are the known findings recorded in the baseline, they do not fail the run
+	Baselined []Finding `json:"baselined"`
+	// Role is the position of the kustomization in the reference graph, one of: root, base, component
+	Role string `json:"role,omitempty"`
+	// References are the directories of the kustomizations referenced by this one
+	References []string `json:"references,omitempty"`
+	// ReferencedBy are the directories of the kustomizations referencing this one
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+}
+
+// Finding is a single validation finding on a rendered resource
+type Finding struct {
+	ApiVersion  string            `json:"apiVersion"`
+	Kind        string            `json:"kind"`
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	Rule        string            `json:"rule,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Remediation string            `json:"remediation,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	FieldPath   string            `json:"fieldPath,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Severity    validate.Severity `json:"severity"`
+	LineNumber  int               `json:"line"`
+	MatchedLine string            `json:"matchedLine"`
+	Context     []string          `json:"context"`
+	SourceFile  string            `json:"sourceFile,omitempty"`
+	SourceLine  int               `json:"sourceLine,omitempty"`
+}
+
+// New creates an empty report for the given path
+func New(path string) *Report {
+	return &Report{
+		Path:           path,
+		Kustomizations: []Kustomization{},
+	}
+}
+
+// NewKustomization creates the result of a single kustomization from its
+// build output, the rendered resources and the content validation findings
+func NewKustomization(c validate.Carrier, resources []k8s.Resource, findings validate.Resources) Kustomization {
+	k := Kustomization{
+		Path:       c.Path,
+		File:       c.File,
+		Status:     StatusSuccess,
+		Stderr:     c.Stderr,
+		Resources:  resources,
+		Findings:   NewFindings(findings),
+		Suppressed: []Finding{},
+		Baselined:  []Finding{},
+	}
+	if k.Resources == nil {
+		k.Resources = []k8s.Resource{}
+	}
+
+	switch {
+	case c.TimedOut:
+		k.Status = StatusTimeout
+		k.Error = c.Err.Error()
+	case c.Err != nil:
+		k.Status = StatusBuildFailed
+		k.Error = c.Err.Error()
+	case findings.Error() != nil:
+		k.Status = StatusValidationFailed
+		k.Error = findings.Error().Error()
+	}
+	return k
+}
+
+// NewFindings converts the validation findings into report findings
+func NewFindings(findings validate.Resources) []Finding {
+	converted := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		// findings without severity are errors
+		severity := f.Severity
+		if severity == "" {
+			severity = validate.SeverityError
+		}
+		converted = append(converted, Finding{
+			ApiVersion:  f.ApiVersion,
+			Kind:        f.Kind,
+			Namespace:   f.Namespace,
+			Name:        f.Name,
+			Rule:        f.Rule,
+			Description: f.Description,
+			Remediation: f.Remediation,
+			Pattern:     f.Pattern,
+			FieldPath:   f.FieldPath,
+			Message:     f.Message,
+			Severity:    severity,
+			LineNumber:  f.LineNumber,
+			MatchedLine: f.MatchedLine,
+			Context:     f.Context,
+			SourceFile:  f.SourceFile,
+			SourceLine:  f.SourceLine,
+		})
+	}
+	return converted
+}
+
+// Add adds the result of a kustomization to the report and updates the summary
+func (r *Report) Add(k Kustomization) {
+	r.Kustomizations = append(r.Kustomizations, k)
+	r.Summary.Total++
+	r.Summary.Findings += len(k.Findings)
+	r.Summary.Suppressed += len(k.Suppressed)
+	r.Summary.Baselined += len(k.Baselined)
+	switch k.Status {
+	case StatusSuccess:
+		r.Summary.Success++
+	case StatusTimeout:
+		r.Summary.Timeout++
+	default:
+		r.Summary.Error++
+	}
+}
+
+// Skip records the directories of kustomizations excluded from discovery
+func (r *Report) Skip(dirs []string) {
+	r.Skipped = append(r.Skipped, dirs...)
+	r.Summary.Skipped += len(dirs)
+}
+
+// Relationship describes the position of the kustomization in the reference graph,
+// e.g. "base of overlays/dev, overlays/prod"
+func (k Kustomization) Relationship() string {
+	switch {
+	case k.Role == "":
+		return ""
+	case len(k.ReferencedBy) > 0:
+		return fmt.Sprintf("%s of %s", k.Role, strings.Join(k.ReferencedBy, ", "))
+	case len(k.References) > 0:
+		return fmt.Sprintf("%s referencing %s", k.Role, strings.Join(k.References, ", "))
+	}
+	return k.Role
+}
+
+// Sort orders the kustomizations by path, so reports are stable across runs
+func (r *Report) Sort() {
+	sort.Slice(r.Kustomizations, func(i, j int) bool {
+		return r.Kustomizations[i].Path < r.Kustomizations[j].Path
+	})
+}
+
+// of reports whether the finding was found on the given resource
+func (f Finding) of(resource k8s.Resource) bool {
+	return f.ApiVersion == resource.ApiVersion && f.Kind == resource.Kind && f.Namespace == resource.Namespace && f.Name == resource.Name
+}
+
+// resource converts the finding back into the validation result it was created from
+func (f Finding) resource() validate.Resource {
+	return validate.Resource{
+		Resource: k8s.Resource{
+			ApiVersion: f.ApiVersion,
+			Kind:       f.Kind,
+			Namespace:  f.Namespace,
+			Name:       f.Name,
+		},
+		Rule:        f.Rule,
+		Description: f.Description,
+		Remediation: f.Remediation,
+		Pattern:     f.Pattern,
+		FieldPath:   f.FieldPath,
+		Message:     f.Message,
+		Severity:    f.Severity,
+		LineNumber:  f.LineNumber,
+		MatchedLine: f.MatchedLine,
+		Context:     f.Context,
+		SourceFile:  f.SourceFile,
+		SourceLine:  f.SourceLine,
+	}
+}
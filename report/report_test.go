@@ -0,0 +1,109 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+func TestNewKustomization(t *testing.T) {
+	resource := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "my-app", Namespace: "default"}
+	tests := []struct {
+		name     string
+		carrier  validate.Carrier
+		findings validate.Resources
+		want     Status
+	}{
+		{
+			name:    "success",
+			carrier: validate.Carrier{Path: "app"},
+			want:    StatusSuccess,
+		},
+		{
+			name:    "build failed",
+			carrier: validate.Carrier{Path: "app", Err: errors.New("exit status 1")},
+			want:    StatusBuildFailed,
+		},
+		{
+			name:    "timeout",
+			carrier: validate.Carrier{Path: "app", Err: errors.New("timeout"), TimedOut: true},
+			want:    StatusTimeout,
+		},
+		{
+			name:     "validation failed",
+			carrier:  validate.Carrier{Path: "app"},
+			findings: validate.Resources{{Resource: resource, Pattern: "PATCH_ME", LineNumber: 6}},
+			want:     StatusValidationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKustomization(tt.carrier, []k8s.Resource{resource}, tt.findings)
+			if k.Status != tt.want {
+				t.Errorf("expected status %s, got: %s", tt.want, k.Status)
+			}
+			if len(k.Findings) != len(tt.findings) {
+				t.Errorf("expected %d findings, got: %d", len(tt.findings), len(k.Findings))
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	r := New("./apps")
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/b"}, nil, validate.Resources{
+		{Pattern: "PATCH_ME", LineNumber: 3, Severity: validate.SeverityWarning},
+		{Pattern: "PATCH_ME", LineNumber: 5, Severity: validate.SeverityWarning},
+	}))
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/a", Err: errors.New("exit status 1")}, nil, nil))
+	r.Skip([]string{"apps/vendor"})
+	r.Sort()
+
+	buf := &bytes.Buffer{}
+	if err := WriteJSON(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Summary.Total != 2 || got.Summary.Success != 1 || got.Summary.Error != 1 || got.Summary.Findings != 2 || got.Summary.Skipped != 1 {
+		t.Errorf("unexpected summary: %+v", got.Summary)
+	}
+	if got.Kustomizations[0].Path != "apps/a" {
+		t.Errorf("expected sorted kustomizations, got: %s first", got.Kustomizations[0].Path)
+	}
+}
+
+func TestKustomization_Relationship(t *testing.T) {
+	tests := []struct {
+		name string
+		k    Kustomization
+		want string
+	}{
+		{name: "no graph", k: Kustomization{}, want: ""},
+		{name: "standalone root", k: Kustomization{Role: "root"}, want: "root"},
+		{
+			name: "root",
+			k:    Kustomization{Role: "root", References: []string{"base", "components/monitoring"}},
+			want: "root referencing base, components/monitoring",
+		},
+		{
+			name: "base",
+			k:    Kustomization{Role: "base", References: []string{"common"}, ReferencedBy: []string{"overlays/dev", "overlays/prod"}},
+			want: "base of overlays/dev, overlays/prod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.k.Relationship(); got != tt.want {
+				t.Errorf("expected %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifToolURI = "https://github.com/redhat-consulting-services/kustomize-validator"
+
+	// RuleBuildFailed is the rule ID of failed kustomize builds
+	RuleBuildFailed = "kustomize-build-failed"
+	// RuleBuildTimeout is the rule ID of kustomize builds exceeding their timeout
+	RuleBuildTimeout = "kustomize-build-timeout"
+	// RuleSchema is the rule ID of resources violating the schema of their kind
+	RuleSchema = validate.RuleSchema
+	// RuleUnknownKind is the rule ID of resources of kinds without schema
+	RuleUnknownKind = validate.RuleUnknownKind
+	// ruleContentPrefix prefixes the rule IDs of plain content checks, followed by the check
+	ruleContentPrefix = "content:"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	Help                 *sarifMessage      `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// BaselineState is set if the findings are compared against a baseline
+	BaselineState string `json:"baselineState,omitempty"`
+	// Suppressions are set on findings suppressed in the source
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifBuilder collects rules and results while keeping the rule indices stable
+type sarifBuilder struct {
+	run     sarifRun
+	indices map[string]int
+}
+
+// sarifLevels maps the finding severities to SARIF levels
+var sarifLevels = map[validate.Severity]string{
+	validate.SeverityInfo:    "note",
+	validate.SeverityWarning: "warning",
+	validate.SeverityError:   "error",
+}
+
+// sarifLevel returns the SARIF level of the severity, unknown severities are errors
+func sarifLevel(severity validate.Severity) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+	return "error"
+}
+
+// sarifRuleInfo describes a rule of the SARIF log
+type sarifRuleInfo struct {
+	id, description, remediation, level string
+}
+
+func (b *sarifBuilder) rule(info sarifRuleInfo) int {
+	if idx, ok := b.indices[info.id]; ok {
+		return idx
+	}
+	rule := sarifRule{
+		ID:                   info.id,
+		ShortDescription:     sarifMessage{Text: info.description},
+		DefaultConfiguration: sarifConfiguration{Level: info.level},
+	}
+	if info.remediation != "" {
+		rule.Help = &sarifMessage{Text: info.remediation}
+	}
+	b.indices[info.id] = len(b.run.Tool.Driver.Rules)
+	b.run.Tool.Driver.Rules = append(b.run.Tool.Driver.Rules, rule)
+	return b.indices[info.id]
+}
+
+func (b *sarifBuilder) result(info sarifRuleInfo, msg, file string, line int) *sarifResult {
+	location := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)},
+		},
+	}
+	if filepath.IsAbs(file) {
+		location.PhysicalLocation.ArtifactLocation.URI = "file://" + filepath.ToSlash(file)
+	} else {
+		location.PhysicalLocation.ArtifactLocation.URIBaseID = "%SRCROOT%"
+	}
+	if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	b.run.Results = append(b.run.Results, sarifResult{
+		RuleID:    info.id,
+		RuleIndex: b.rule(info),
+		Level:     info.level,
+		Message:   sarifMessage{Text: msg},
+		Locations: []sarifLocation{location},
+	})
+	return &b.run.Results[len(b.run.Results)-1]
+}
+
+// finding adds the finding of the kustomization as a result
+func (b *sarifBuilder) finding(k Kustomization, f Finding) *sarifResult {
+	// point to the file introducing the finding if it is known and local
+	file, line := k.File, 0
+	if f.SourceFile != "" && !strings.Contains(f.SourceFile, "//") {
+		file, line = f.SourceFile, f.SourceLine
+	}
+	if f.Pattern == "" {
+		msg := f.Message
+		if f.FieldPath != "" {
+			msg = f.FieldPath + ": " + msg
+		}
+		return b.result(f.sarifRule(), fmt.Sprintf("%s in resource %s/%s/%s/%s rendered from %s",
+			msg, f.ApiVersion, f.Kind, f.Namespace, f.Name, k.Path),
+			file, line)
+	}
+	field := ""
+	if f.FieldPath != "" {
+		field = " field " + f.FieldPath + " of"
+	}
+	return b.result(f.sarifRule(), fmt.Sprintf("found '%s' in%s resource %s/%s/%s/%s rendered from %s: %s",
+		f.Pattern, field, f.ApiVersion, f.Kind, f.Namespace, f.Name, k.Path, strings.TrimSpace(f.MatchedLine)),
+		file, line)
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log. Failed builds become results
+// located at their kustomization file, content findings are located at the source
+// file that introduced them. Suppressed findings are included with an in-source
+// suppression, so they remain auditable. If a baseline is used, findings are marked
+// as new or, if they are recorded in the baseline, as unchanged.
+func WriteSARIF(w io.Writer, r *Report) error {
+	b := &sarifBuilder{
+		run: sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kustomize-validator",
+				InformationURI: sarifToolURI,
+				Rules:          []sarifRule{},
+			}},
+			Results: []sarifResult{},
+		},
+		indices: map[string]int{},
+	}
+
+	for _, k := range r.Kustomizations {
+		switch k.Status {
+		case StatusTimeout:
+			b.result(sarifRuleInfo{id: RuleBuildTimeout, description: "kustomize build exceeded its timeout", level: "error"},
+				fmt.Sprintf("kustomize build of %s timed out: %s", k.Path, k.Error), k.File, 0)
+		case StatusBuildFailed:
+			msg := fmt.Sprintf("kustomize build of %s failed: %s", k.Path, k.Error)
+			if stderr := strings.TrimSpace(k.Stderr); stderr != "" {
+				msg += "\n" + stderr
+			}
+			b.result(sarifRuleInfo{id: RuleBuildFailed, description: "kustomize build failed", level: "error"}, msg, k.File, 0)
+		}
+
+		for _, f := range k.Findings {
+			result := b.finding(k, f)
+			if r.Baseline != "" {
+				result.BaselineState = "new"
+			}
+		}
+		for _, f := range k.Baselined {
+			result := b.finding(k, f)
+			result.BaselineState = "unchanged"
+		}
+		for _, f := range k.Suppressed {
+			result := b.finding(k, f)
+			result.Suppressions = []sarifSuppression{{
+				Kind:          "inSource",
+				Justification: "suppressed by the " + validate.IgnoreAnnotation + " annotation",
+			}}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{b.run},
+	})
+}
+
+// sarifRule returns the SARIF rule of the finding. Named rules and checks keep their ID,
+// plain content checks are prefixed to separate them from the rules of the validator.
+func (f Finding) sarifRule() sarifRuleInfo {
+	info := sarifRuleInfo{id: f.Rule, description: f.Description, remediation: f.Remediation, level: sarifLevel(f.Severity)}
+	switch {
+	case f.Description != "":
+	case f.Rule == RuleUnknownKind:
+		info.description = "no schema is known for the kind of the rendered resource"
+	case f.Rule == RuleSchema:
+		info.description = "rendered resource violates the schema of its kind"
+	case f.Pattern == "":
+		info.description = fmt.Sprintf("rendered resource violates the check %s", f.Rule)
+	default:
+		check := f.Rule
+		if check == "" {
+			check = f.Pattern
+		}
+		info.id = ruleContentPrefix + check
+		info.description = fmt.Sprintf("rendered output contains '%s'", f.Pattern)
+	}
+	return info
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	resource := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "my-app", Namespace: "default"}
+	r := New("./apps")
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/a", File: "apps/a/kustomization.yaml", Err: errors.New("exit status 1")}, nil, nil))
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/b", File: "apps/b/kustomization.yaml"}, []k8s.Resource{resource}, validate.Resources{
+		{Resource: resource, Pattern: "PATCH_ME", LineNumber: 6},
+		{Resource: resource, Pattern: "PATCH_ME", LineNumber: 8},
+	}))
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/c", File: "apps/c/kustomization.yaml"}, nil, nil))
+
+	buf := &bytes.Buffer{}
+	if err := WriteSARIF(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	run := got.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("expected 2 rules, got: %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got: %d", len(run.Results))
+	}
+	if run.Results[0].RuleID != RuleBuildFailed {
+		t.Errorf("expected rule %s, got: %s", RuleBuildFailed, run.Results[0].RuleID)
+	}
+	if uri := run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "apps/b/kustomization.yaml" {
+		t.Errorf("expected location in kustomization file, got: %s", uri)
+	}
+}
+
+func TestWriteSARIF_rules(t *testing.T) {
+	resource := k8s.Resource{ApiVersion: "apps/v1", Kind: "Deployment", Name: "my-app"}
+	r := New("./apps")
+	r.Add(NewKustomization(validate.Carrier{Path: "apps/a", File: "apps/a/kustomization.yaml"}, []k8s.Resource{resource}, validate.Resources{
+		{Resource: resource, Rule: "no-latest-tag", Description: "Images must be pinned to a version", Remediation: "Use an immutable tag",
+			Severity: validate.SeverityWarning, Pattern: ":latest$", FieldPath: "spec.template.spec.containers[0].image"},
+		{Resource: resource, Rule: "PATCH_ME", Severity: validate.SeverityError, Pattern: "PATCH_ME"},
+		{Resource: resource, Rule: validate.RuleUnknownKind, Severity: validate.SeverityInfo, FieldPath: "kind", Message: "no schema found"},
+		{Resource: resource, Rule: validate.RuleSchema, Severity: validate.SeverityError, FieldPath: "spec.replicas", Message: "expected integer"},
+		{Resource: resource, Rule: "owner-label", Severity: validate.SeverityError, Message: "the owner label is missing"},
+	}))
+
+	buf := &bytes.Buffer{}
+	if err := WriteSARIF(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	run := got.Runs[0]
+	want := []struct{ id, level string }{
+		{id: "no-latest-tag", level: "warning"},
+		{id: ruleContentPrefix + "PATCH_ME", level: "error"},
+		{id: RuleUnknownKind, level: "note"},
+		{id: RuleSchema, level: "error"},
+		{id: "owner-label", level: "error"},
+	}
+	if len(run.Results) != len(want) {
+		t.Fatalf("expected %d results, got: %d", len(want), len(run.Results))
+	}
+	for i, w := range want {
+		if run.Results[i].RuleID != w.id || run.Results[i].Level != w.level {
+			t.Errorf("expected rule %s with level %s, got: %s with level %s", w.id, w.level, run.Results[i].RuleID, run.Results[i].Level)
+		}
+	}
+	if msg := run.Results[3].Message.Text; msg != "spec.replicas: expected integer in resource apps/v1/Deployment//my-app rendered from apps/a" {
+		t.Errorf("expected the field path in the message of the schema violation, got: %s", msg)
+	}
+	if msg := run.Results[4].Message.Text; msg != "the owner label is missing in resource apps/v1/Deployment//my-app rendered from apps/a" {
+		t.Errorf("expected no field path in the message of the check, got: %s", msg)
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ShortDescription.Text != "Images must be pinned to a version" || rule.Help == nil || rule.Help.Text != "Use an immutable tag" {
+		t.Errorf("expected description and remediation of the named rule, got: %+v", rule)
+	}
+}
+
+func TestWriteSARIF_suppressed(t *testing.T) {
+	resource := k8s.Resource{ApiVersion: "v1", Kind: "ConfigMap", Name: "conventions"}
+	k := NewKustomization(validate.Carrier{Path: "apps/a", File: "apps/a/kustomization.yaml"}, []k8s.Resource{resource}, nil)
+	k.Suppressed = NewFindings(validate.Resources{{Resource: resource, Rule: "PATCH_ME", Pattern: "PATCH_ME", LineNumber: 8}})
+	r := New("./apps")
+	r.Add(k)
+	if r.Summary.Suppressed != 1 || r.Summary.Findings != 0 {
+		t.Errorf("unexpected summary: %+v", r.Summary)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteSARIF(buf, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	results := got.Runs[0].Results
+	if len(results) != 1 || len(results[0].Suppressions) != 1 || results[0].Suppressions[0].Kind != "inSource" {
+		t.Errorf("expected a single suppressed result, got: %+v", results)
+	}
+}
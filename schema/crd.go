@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	crdGroup = "apiextensions.k8s.io"
+	crdKind  = "CustomResourceDefinition"
+	// objectMetaRef is the definition of the metadata of every resource
+	objectMetaRef = "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+)
+
+// customResourceDefinition is the part of a CustomResourceDefinition describing its schemas
+type customResourceDefinition struct {
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Schema struct {
+				OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+		// apiextensions.k8s.io/v1beta1 declares a single schema for all versions
+		Version    string `json:"version"`
+		Validation struct {
+			OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+		} `json:"validation"`
+	} `json:"spec"`
+}
+
+// IsCRD reports whether the given apiVersion and kind identify a CustomResourceDefinition
+func IsCRD(apiVersion, kind string) bool {
+	return kind == crdKind && ParseGroupVersionKind(apiVersion, kind).Group == crdGroup
+}
+
+// AddCRD registers the schemas of all versions of the given CustomResourceDefinition.
+// Versions without schema are registered as free-form, so they are known kinds.
+func (v *Validator) AddCRD(object map[string]any) error {
+	// round trip through JSON to decode the generic object into the schema types
+	content, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	var crd customResourceDefinition
+	if err := json.Unmarshal(content, &crd); err != nil {
+		return fmt.Errorf("invalid CustomResourceDefinition: %w", err)
+	}
+	if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+		return errors.New("invalid CustomResourceDefinition: missing group or kind")
+	}
+
+	for _, version := range crd.Spec.Versions {
+		s := version.Schema.OpenAPIV3Schema
+		if s == nil {
+			s = crd.Spec.Validation.OpenAPIV3Schema
+		}
+		v.AddKind(GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}, v.rootSchema(s))
+	}
+	if len(crd.Spec.Versions) == 0 && crd.Spec.Version != "" {
+		v.AddKind(GroupVersionKind{Group: crd.Spec.Group, Version: crd.Spec.Version, Kind: crd.Spec.Names.Kind}, v.rootSchema(crd.Spec.Validation.OpenAPIV3Schema))
+	}
+	return nil
+}
+
+// rootSchema completes the schema of a custom resource with the fields the API server
+// accepts on every resource, even if the CustomResourceDefinition does not declare them
+func (v *Validator) rootSchema(s *Schema) *Schema {
+	if s == nil {
+		return &Schema{Type: "object", PreserveUnknownFields: true}
+	}
+	root := *s
+	root.Properties = map[string]*Schema{}
+	for name, property := range s.Properties {
+		root.Properties[name] = property
+	}
+	for _, name := range []string{"apiVersion", "kind"} {
+		if _, ok := root.Properties[name]; !ok {
+			root.Properties[name] = &Schema{Type: "string"}
+		}
+	}
+	if _, ok := v.definitions[strings.TrimPrefix(objectMetaRef, "#/definitions/")]; ok {
+		// the metadata of custom resources is always validated as ObjectMeta
+		root.Properties["metadata"] = &Schema{Ref: objectMetaRef}
+	} else if _, ok := root.Properties["metadata"]; !ok {
+		root.Properties["metadata"] = &Schema{Type: "object"}
+	}
+	return &root
+}
+
+// AddCRDsFromDir registers all CustomResourceDefinitions found in the YAML and
+// JSON files within the given directory and its subdirectories
+func (v *Validator) AddCRDsFromDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		dec := yaml.NewDecoder(file)
+		for {
+			var object map[string]any
+			err := dec.Decode(&object)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			apiVersion, _ := object["apiVersion"].(string)
+			kind, _ := object["kind"].(string)
+			if !IsCRD(apiVersion, kind) {
+				continue
+			}
+			if err := v.AddCRD(object); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var crdExample = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+spec:
+  group: cert-manager.io
+  names:
+    kind: Certificate
+    plural: certificates
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required:
+            - secretName
+            properties:
+              secretName:
+                type: string
+              dnsNames:
+                type: array
+                items:
+                  type: string
+              duration:
+                type: string
+`
+
+var certificateExample = `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: example
+spec:
+  secretName: example-tls
+  dnsNames:
+  - example.com
+`
+
+func TestValidator_AddCRD(t *testing.T) {
+	validator, err := Kubernetes(LatestKubernetesVersion())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validator.AddCRD(parse(t, crdExample)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certificate := ParseGroupVersionKind("cert-manager.io/v1", "Certificate")
+
+	tests := []struct {
+		name   string
+		modify func(object map[string]any)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(object map[string]any) {},
+		},
+		{
+			name: "unknown field",
+			modify: func(object map[string]any) {
+				object["spec"].(map[string]any)["dnsName"] = "example.com"
+			},
+			want: []string{"spec.dnsName: unknown field"},
+		},
+		{
+			name: "missing required field",
+			modify: func(object map[string]any) {
+				delete(object["spec"].(map[string]any), "secretName")
+			},
+			want: []string{"spec.secretName: required field is missing"},
+		},
+		{
+			name: "metadata validated as ObjectMeta",
+			modify: func(object map[string]any) {
+				object["metadata"].(map[string]any)["label"] = "example"
+			},
+			want: []string{"metadata.label: unknown field"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := parse(t, certificateExample)
+			tt.modify(object)
+			fieldErrors, known := validator.Validate(certificate, object)
+			if !known {
+				t.Fatalf("expected %s to be known", certificate)
+			}
+			var got []string
+			for _, fieldError := range fieldErrors {
+				got = append(got, fieldError.Path.String()+": "+fieldError.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+
+	if validator.Knows(ParseGroupVersionKind("cert-manager.io/v1alpha2", "Certificate")) {
+		t.Errorf("expected versions not declared by the CustomResourceDefinition to be unknown")
+	}
+}
+
+func TestValidator_AddCRDsFromDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "cert-manager"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// the CustomResourceDefinition is preceded by another document
+	content := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: cert-manager\n---\n" + crdExample
+	if err := os.WriteFile(filepath.Join(dir, "cert-manager", "crds.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# CRDs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := NewValidator()
+	if err := validator.AddCRDsFromDir(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !validator.Knows(ParseGroupVersionKind("cert-manager.io/v1", "Certificate")) {
+		t.Errorf("expected Certificate to be known")
+	}
+	if validator.Knows(ParseGroupVersionKind("v1", "Namespace")) {
+		t.Errorf("expected Namespace not to be known")
+	}
+}
@@ -0,0 +1,132 @@
+// Command gen downloads the OpenAPI specification of the given Kubernetes versions
+// from the Go module proxy and writes the pruned definitions bundled with the validator.
+//
+// Usage:
+//
+//	go run ./internal/gen -out kubernetes 1.36.0 1.35.0
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keep are the schema keywords used by the validator, everything else is pruned
+var keep = map[string]bool{
+	"type":                                 true,
+	"format":                               true,
+	"$ref":                                 true,
+	"properties":                           true,
+	"additionalProperties":                 true,
+	"items":                                true,
+	"required":                             true,
+	"enum":                                 true,
+	"x-kubernetes-group-version-kind":      true,
+	"x-kubernetes-int-or-string":           true,
+	"x-kubernetes-preserve-unknown-fields": true,
+}
+
+func main() {
+	out := flag.String("out", "kubernetes", "output directory")
+	proxy := flag.String("proxy", "https://proxy.golang.org", "Go module proxy to download the kubernetes module from")
+	flag.Parse()
+
+	for _, version := range flag.Args() {
+		if err := generate(*proxy, *out, version); err != nil {
+			log.Fatalf("failed to generate schema for %s: %s", version, err)
+		}
+	}
+}
+
+func generate(proxy, out, version string) error {
+	version = strings.TrimPrefix(version, "v")
+	resp, err := http.Get(fmt.Sprintf("%s/k8s.io/kubernetes/@v/v%s.zip", proxy, version))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+	spec, err := archive.Open(fmt.Sprintf("k8s.io/kubernetes@v%s/api/openapi-spec/swagger.json", version))
+	if err != nil {
+		return err
+	}
+	defer spec.Close()
+
+	var swagger struct {
+		Definitions map[string]any `json:"definitions"`
+	}
+	if err := json.NewDecoder(spec).Decode(&swagger); err != nil {
+		return err
+	}
+	for name, definition := range swagger.Definitions {
+		swagger.Definitions[name] = prune(definition)
+	}
+	// quantities are serialized as strings, but numbers are accepted as well
+	if quantity, ok := swagger.Definitions["io.k8s.apimachinery.pkg.api.resource.Quantity"].(map[string]any); ok {
+		quantity["format"] = "quantity"
+	}
+
+	// file names only contain major and minor version, the schema does not change in patch releases
+	parts := strings.SplitN(version, ".", 3)
+	file, err := os.Create(filepath.Join(out, fmt.Sprintf("v%s.%s.json.gz", parts[0], parts[1])))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(gz).Encode(swagger.Definitions); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// prune removes all keywords not used by the validator from the schema
+func prune(value any) any {
+	schema, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	pruned := map[string]any{}
+	for key, v := range schema {
+		if !keep[key] {
+			continue
+		}
+		switch key {
+		case "properties":
+			properties := map[string]any{}
+			for name, property := range v.(map[string]any) {
+				properties[name] = prune(property)
+			}
+			pruned[key] = properties
+		case "items", "additionalProperties":
+			pruned[key] = prune(v)
+		default:
+			pruned[key] = v
+		}
+	}
+	return pruned
+}
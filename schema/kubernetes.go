@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run ./internal/gen -out kubernetes 1.31.0 1.32.0 1.33.0 1.34.0 1.35.0 1.36.0
+
+//go:embed kubernetes/*.json.gz
+var kubernetesSchemas embed.FS
+
+// KubernetesVersions returns the bundled Kubernetes versions, oldest first
+func KubernetesVersions() []string {
+	entries, _ := kubernetesSchemas.ReadDir("kubernetes")
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		versions = append(versions, strings.TrimSuffix(entry.Name(), ".json.gz"))
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return minorOf(versions[i]) < minorOf(versions[j])
+	})
+	return versions
+}
+
+// LatestKubernetesVersion returns the newest bundled Kubernetes version
+func LatestKubernetesVersion() string {
+	versions := KubernetesVersions()
+	return versions[len(versions)-1]
+}
+
+// Kubernetes creates a validator with the bundled schemas of the given Kubernetes
+// version. The version may be given with or without the "v" prefix and patch version,
+// e.g. v1.33, 1.33 or 1.33.2.
+func Kubernetes(version string) (*Validator, error) {
+	version = "v" + strings.TrimPrefix(version, "v")
+	if parts := strings.Split(version, "."); len(parts) > 2 {
+		version = strings.Join(parts[:2], ".")
+	}
+
+	file, err := kubernetesSchemas.Open("kubernetes/" + version + ".json.gz")
+	if err != nil {
+		return nil, fmt.Errorf("unsupported kubernetes version %s, supported versions: %s", version, strings.Join(KubernetesVersions(), ", "))
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions map[string]*Schema
+	if err := json.NewDecoder(gz).Decode(&definitions); err != nil {
+		return nil, fmt.Errorf("failed to read schemas of kubernetes %s: %w", version, err)
+	}
+	v := NewValidator()
+	v.AddDefinitions(definitions)
+	return v, nil
+}
+
+// minorOf returns the minor version of a version like v1.33
+func minorOf(version string) int {
+	_, minor, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(minor)
+	return n
+}
@@ -0,0 +1,309 @@
+// Package schema validates rendered resources against OpenAPI schemas.
+//
+// The schemas of the built-in Kubernetes kinds are bundled for several
+// Kubernetes versions, so validation works fully offline. Only the subset
+// of OpenAPI used by Kubernetes is supported: types, formats, properties,
+// additional properties, items, required fields, enums and the
+// x-kubernetes-int-or-string, x-kubernetes-preserve-unknown-fields and
+// x-kubernetes-embedded-resource extensions.
+//
+// Schemas of custom resources are registered from CustomResourceDefinitions.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// Schema is an OpenAPI schema
+type Schema struct {
+	Ref                   string             `json:"$ref,omitempty"`
+	Type                  string             `json:"type,omitempty"`
+	Format                string             `json:"format,omitempty"`
+	Properties            map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties  *SchemaOrBool      `json:"additionalProperties,omitempty"`
+	Items                 *Schema            `json:"items,omitempty"`
+	Required              []string           `json:"required,omitempty"`
+	Enum                  []any              `json:"enum,omitempty"`
+	IntOrString           bool               `json:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool               `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	EmbeddedResource      bool               `json:"x-kubernetes-embedded-resource,omitempty"`
+	GroupVersionKinds     []GroupVersionKind `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+// SchemaOrBool is either a schema or a boolean allowing or forbidding any value
+type SchemaOrBool struct {
+	Allows bool
+	Schema *Schema
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *SchemaOrBool) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Allows); err == nil {
+		return nil
+	}
+	s.Allows = true
+	return json.Unmarshal(data, &s.Schema)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s SchemaOrBool) MarshalJSON() ([]byte, error) {
+	if s.Schema != nil {
+		return json.Marshal(s.Schema)
+	}
+	return json.Marshal(s.Allows)
+}
+
+// GroupVersionKind identifies the kind of a resource
+type GroupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// ParseGroupVersionKind creates a GroupVersionKind from an apiVersion and kind
+func ParseGroupVersionKind(apiVersion, kind string) GroupVersionKind {
+	group, version, found := strings.Cut(apiVersion, "/")
+	if !found {
+		// the core group has no name, e.g. apiVersion: v1
+		group, version = "", apiVersion
+	}
+	return GroupVersionKind{Group: group, Version: version, Kind: kind}
+}
+
+func (gvk GroupVersionKind) String() string {
+	if gvk.Group == "" {
+		return fmt.Sprintf("%s/%s", gvk.Version, gvk.Kind)
+	}
+	return fmt.Sprintf("%s/%s/%s", gvk.Group, gvk.Version, gvk.Kind)
+}
+
+// FieldError is a field of an object violating its schema
+type FieldError struct {
+	Path    k8s.FieldPath
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validator validates objects against the schemas of their kinds
+type Validator struct {
+	definitions map[string]*Schema
+	kinds       map[GroupVersionKind]*Schema
+}
+
+// NewValidator creates a validator without any schemas
+func NewValidator() *Validator {
+	return &Validator{
+		definitions: map[string]*Schema{},
+		kinds:       map[GroupVersionKind]*Schema{},
+	}
+}
+
+// AddDefinitions adds OpenAPI definitions to the validator. Definitions may
+// reference each other with "#/definitions/<name>" and are registered for
+// the kinds listed in their x-kubernetes-group-version-kind extension.
+func (v *Validator) AddDefinitions(definitions map[string]*Schema) {
+	for name, definition := range definitions {
+		v.definitions[name] = definition
+		for _, gvk := range definition.GroupVersionKinds {
+			v.kinds[gvk] = definition
+		}
+	}
+}
+
+// AddKind registers the schema of a kind
+func (v *Validator) AddKind(gvk GroupVersionKind, schema *Schema) {
+	v.kinds[gvk] = schema
+}
+
+// Knows reports whether the validator has a schema for the given kind
+func (v *Validator) Knows(gvk GroupVersionKind) bool {
+	_, ok := v.kinds[gvk]
+	return ok
+}
+
+// Validate validates the object against the schema of its kind. It returns
+// false if there is no schema for the kind of the object.
+func (v *Validator) Validate(gvk GroupVersionKind, object map[string]any) ([]FieldError, bool) {
+	schema, ok := v.kinds[gvk]
+	if !ok {
+		return nil, false
+	}
+	var errs []FieldError
+	v.validate(schema, object, k8s.FieldPath{}, &errs)
+	return errs, true
+}
+
+// resolve follows the reference of the schema
+func (v *Validator) resolve(s *Schema) *Schema {
+	for depth := 0; s != nil && s.Ref != "" && depth < 32; depth++ {
+		s = v.definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	return s
+}
+
+func (v *Validator) validate(s *Schema, value any, path k8s.FieldPath, errs *[]FieldError) {
+	s = v.resolve(s)
+	// unset values are accepted by the API server for any field
+	if s == nil || value == nil {
+		return
+	}
+	fail := func(format string, a ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if s.IntOrString || s.Format == "int-or-string" {
+		if !isInteger(value) && !isString(value) {
+			fail("expected integer or string, got %s", typeOf(value))
+		}
+		return
+	}
+	if s.Format == "quantity" {
+		if !isNumber(value) && !isString(value) {
+			fail("expected quantity, got %s", typeOf(value))
+		}
+		return
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("unsupported value %v, expected one of %v", value, s.Enum)
+	}
+
+	switch s.Type {
+	case "object":
+		v.validateObject(s, value, path, errs, fail)
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			fail("expected array, got %s", typeOf(value))
+			return
+		}
+		for i, item := range list {
+			v.validate(s.Items, item, path.Index(i), errs)
+		}
+	case "string":
+		if !isString(value) {
+			fail("expected string, got %s", typeOf(value))
+		}
+	case "integer":
+		if !isInteger(value) {
+			fail("expected integer, got %s", typeOf(value))
+		}
+	case "number":
+		if !isNumber(value) {
+			fail("expected number, got %s", typeOf(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean, got %s", typeOf(value))
+		}
+	case "":
+		// untyped schemas with properties describe objects, others accept anything
+		if len(s.Properties) > 0 {
+			v.validateObject(s, value, path, errs, fail)
+		}
+	}
+}
+
+func (v *Validator) validateObject(s *Schema, value any, path k8s.FieldPath, errs *[]FieldError, fail func(string, ...any)) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		fail("expected object, got %s", typeOf(value))
+		return
+	}
+
+	// sort the keys, so the errors are reported in a stable order
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if property, ok := s.Properties[key]; ok {
+			v.validate(property, object[key], path.Child(key), errs)
+			continue
+		}
+		switch {
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			v.validate(s.AdditionalProperties.Schema, object[key], path.Child(key), errs)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Allows:
+		case s.PreserveUnknownFields:
+		case s.EmbeddedResource && (key == "apiVersion" || key == "kind" || key == "metadata"):
+		case len(s.Properties) == 0 && s.AdditionalProperties == nil:
+			// objects without any declared fields are free-form
+		default:
+			*errs = append(*errs, FieldError{Path: path.Child(key), Message: "unknown field"})
+		}
+	}
+
+	for _, required := range s.Required {
+		if _, ok := object[required]; !ok {
+			*errs = append(*errs, FieldError{Path: path.Child(required), Message: "required field is missing"})
+		}
+	}
+}
+
+func isString(value any) bool {
+	switch value.(type) {
+	case string, time.Time:
+		return true
+	}
+	return false
+}
+
+func isInteger(value any) bool {
+	switch v := value.(type) {
+	case int, int32, int64, uint, uint32, uint64:
+		return true
+	case float64:
+		return v == float64(int64(v))
+	}
+	return false
+}
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case int, int32, int64, uint, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// typeOf returns the OpenAPI type name of a value
+func typeOf(value any) string {
+	switch {
+	case isString(value):
+		return "string"
+	case isInteger(value):
+		return "integer"
+	case isNumber(value):
+		return "number"
+	}
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var deploymentExample = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    app: my-app
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: my-app
+        image: my-app:1.0.0
+        ports:
+        - containerPort: 8080
+        resources:
+          limits:
+            cpu: 0.5
+            memory: 128Mi
+        livenessProbe:
+          httpGet:
+            port: http
+`
+
+func parse(t *testing.T, doc string) map[string]any {
+	t.Helper()
+	var object map[string]any
+	if err := yaml.Unmarshal([]byte(doc), &object); err != nil {
+		t.Fatal(err)
+	}
+	return object
+}
+
+func TestValidator_Validate(t *testing.T) {
+	validator, err := Kubernetes(LatestKubernetesVersion())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deployment := ParseGroupVersionKind("apps/v1", "Deployment")
+
+	tests := []struct {
+		name   string
+		modify func(object map[string]any)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(object map[string]any) {},
+		},
+		{
+			name: "wrong type",
+			modify: func(object map[string]any) {
+				object["spec"].(map[string]any)["replicas"] = "two"
+			},
+			want: []string{"spec.replicas: expected integer, got string"},
+		},
+		{
+			name: "unknown field",
+			modify: func(object map[string]any) {
+				container := object["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+				container["ports"] = []any{map[string]any{"containerport": 8080}}
+			},
+			want: []string{
+				"spec.template.spec.containers[0].ports[0].containerport: unknown field",
+				"spec.template.spec.containers[0].ports[0].containerPort: required field is missing",
+			},
+		},
+		{
+			name: "label value must be a string",
+			modify: func(object map[string]any) {
+				object["metadata"].(map[string]any)["labels"] = map[string]any{"enabled": true}
+			},
+			want: []string{"metadata.labels.enabled: expected string, got boolean"},
+		},
+		{
+			name: "missing required field",
+			modify: func(object map[string]any) {
+				delete(object["spec"].(map[string]any), "selector")
+			},
+			want: []string{"spec.selector: required field is missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := parse(t, deploymentExample)
+			tt.modify(object)
+			errs, ok := validator.Validate(deployment, object)
+			if !ok {
+				t.Fatalf("expected schema for %s", deployment)
+			}
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestKubernetes(t *testing.T) {
+	for _, version := range KubernetesVersions() {
+		validator, err := Kubernetes(version)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", version, err)
+		}
+		if !validator.Knows(ParseGroupVersionKind("v1", "ConfigMap")) {
+			t.Errorf("expected ConfigMap schema in %s", version)
+		}
+	}
+
+	if _, err := Kubernetes("1.33.2"); err != nil {
+		t.Errorf("expected patch versions to be accepted: %v", err)
+	}
+	if _, err := Kubernetes("1.2"); err == nil {
+		t.Errorf("expected error for unsupported version")
+	}
+}
@@ -0,0 +1,172 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Names of the build engines, see NewBuilder
+const (
+	EngineExec     = "exec"
+	EngineEmbedded = "embedded"
+)
+
+// Builder renders the kustomization in a directory
+type Builder interface {
+	// Build builds the kustomization in dir with the given kustomize build flags and
+	// returns the rendered resources as YAML stream and the diagnostic output
+	Build(ctx context.Context, dir string, flags []string) (stdout string, stderr string, err error)
+}
+
+// NewBuilder returns the builder of the given engine, exec runs the kustomize
+// binary from PATH, embedded builds in-process with the kustomize Go API
+func NewBuilder(engine string) (Builder, error) {
+	switch engine {
+	case EngineExec, "":
+		return ExecBuilder{}, nil
+	case EngineEmbedded:
+		return EmbeddedBuilder{}, nil
+	}
+	return nil, fmt.Errorf("unsupported engine %q, must be one of: %s, %s", engine, EngineExec, EngineEmbedded)
+}
+
+// ValidateBuildFlags returns an error if the engine does not support one of the
+// kustomize build flags. If flags is nil, DefaultBuildFlags are validated.
+func ValidateBuildFlags(engine string, flags []string) error {
+	if engine != EngineEmbedded {
+		// the kustomize binary reports unknown flags itself
+		return nil
+	}
+	if flags == nil {
+		flags = DefaultBuildFlags
+	}
+	_, err := embeddedOptions(flags)
+	return err
+}
+
+// ExecBuilder runs kustomize build with the kustomize binary from PATH
+type ExecBuilder struct{}
+
+func (ExecBuilder) Build(ctx context.Context, dir string, flags []string) (string, string, error) {
+	args := append([]string{"build"}, flags...)
+
+	stderrWriter := bytes.NewBuffer([]byte{})
+	stdoutWriter := bytes.NewBuffer([]byte{})
+	cmd := exec.CommandContext(ctx, "kustomize", append(args, dir)...)
+	cmd.Stderr = stderrWriter
+	cmd.Stdout = stdoutWriter
+	// do not wait forever on child processes (e.g. helm) keeping the output pipes open
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	return stdoutWriter.String(), stderrWriter.String(), err
+}
+
+// EmbeddedBuilder builds kustomizations in-process with the kustomize Go API, so
+// no kustomize binary is required. Helm charts still require the helm binary.
+// Errors are returned as reported by kustomize, the diagnostic output is empty.
+type EmbeddedBuilder struct{}
+
+func (EmbeddedBuilder) Build(ctx context.Context, dir string, flags []string) (string, string, error) {
+	options, err := embeddedOptions(flags)
+	if err != nil {
+		return "", "", err
+	}
+
+	type result struct {
+		yaml []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resources, err := krusty.MakeKustomizer(options).Run(filesys.MakeFsOnDisk(), dir)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		yaml, err := resources.AsYaml()
+		done <- result{yaml: yaml, err: err}
+	}()
+
+	// the build cannot be interrupted, on timeout it is abandoned and finishes in the background
+	select {
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	case r := <-done:
+		return string(r.yaml), "", r.err
+	}
+}
+
+// embeddedOptions converts the kustomize build flags into the options of the embedded
+// build. Flags without an equivalent are rejected rather than silently ignored.
+func embeddedOptions(flags []string) (*krusty.Options, error) {
+	options := krusty.MakeDefaultOptions()
+	for i := 0; i < len(flags); i++ {
+		name, value, hasValue := strings.Cut(flags[i], "=")
+		// value returns the value of the flag, given inline or as next argument
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(flags) {
+				return "", fmt.Errorf("build flag %s requires a value", name)
+			}
+			i++
+			return flags[i], nil
+		}
+
+		switch name {
+		case "--enable-helm":
+			options.PluginConfig.HelmConfig.Enabled = true
+			if options.PluginConfig.HelmConfig.Command == "" {
+				options.PluginConfig.HelmConfig.Command = "helm"
+			}
+		case "--helm-command":
+			command, err := next()
+			if err != nil {
+				return nil, err
+			}
+			options.PluginConfig.HelmConfig.Command = command
+		case "--enable-alpha-plugins":
+			options.PluginConfig.PluginRestrictions = types.PluginRestrictionsNone
+		case "--enable-exec":
+			options.PluginConfig.FnpLoadingOptions.EnableExec = true
+		case "--enable-managedby-label":
+			options.AddManagedbyLabel = true
+		case "--load-restrictor":
+			restrictor, err := next()
+			if err != nil {
+				return nil, err
+			}
+			switch restrictor {
+			case types.LoadRestrictionsRootOnly.String():
+				options.LoadRestrictions = types.LoadRestrictionsRootOnly
+			case types.LoadRestrictionsNone.String():
+				options.LoadRestrictions = types.LoadRestrictionsNone
+			default:
+				return nil, fmt.Errorf("unsupported value %q of build flag %s", restrictor, name)
+			}
+		case "--reorder":
+			reorder, err := next()
+			if err != nil {
+				return nil, err
+			}
+			switch krusty.ReorderOption(reorder) {
+			case krusty.ReorderOptionLegacy, krusty.ReorderOptionNone:
+				options.Reorder = krusty.ReorderOption(reorder)
+			default:
+				return nil, fmt.Errorf("unsupported value %q of build flag %s", reorder, name)
+			}
+		default:
+			return nil, fmt.Errorf("build flag %s is not supported by the %s engine", name, EngineEmbedded)
+		}
+	}
+	return options, nil
+}
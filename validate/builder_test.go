@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+func TestEmbeddedBuilder_Build(t *testing.T) {
+	stdout, stderr, err := EmbeddedBuilder{}.Build(context.Background(), "../_tests/app3", DefaultBuildFlags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr != "" {
+		t.Errorf("expected no diagnostic output, got: %s", stderr)
+	}
+	for _, kind := range []string{"kind: Deployment", "kind: Service"} {
+		if !strings.Contains(stdout, kind) {
+			t.Errorf("expected %s in output:\n%s", kind, stdout)
+		}
+	}
+
+	if _, _, err := (EmbeddedBuilder{}).Build(context.Background(), "../_tests/does-not-exist", nil); err == nil {
+		t.Errorf("expected error for missing kustomization")
+	}
+}
+
+func Test_embeddedOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		check   func(options *krusty.Options) bool
+		wantErr bool
+	}{
+		{
+			name:  "default flags",
+			flags: DefaultBuildFlags,
+			check: func(o *krusty.Options) bool {
+				return o.PluginConfig.HelmConfig.Enabled && o.PluginConfig.HelmConfig.Command == "helm" &&
+					o.PluginConfig.PluginRestrictions == types.PluginRestrictionsNone
+			},
+		},
+		{
+			name:  "load restrictor as next argument",
+			flags: []string{"--load-restrictor", "LoadRestrictionsNone"},
+			check: func(o *krusty.Options) bool { return o.LoadRestrictions == types.LoadRestrictionsNone },
+		},
+		{
+			name:  "inline values",
+			flags: []string{"--helm-command=/usr/local/bin/helm", "--enable-helm", "--reorder=legacy"},
+			check: func(o *krusty.Options) bool {
+				return o.PluginConfig.HelmConfig.Command == "/usr/local/bin/helm" && o.Reorder == krusty.ReorderOptionLegacy
+			},
+		},
+		{name: "missing value", flags: []string{"--load-restrictor"}, wantErr: true},
+		{name: "invalid value", flags: []string{"--reorder=random"}, wantErr: true},
+		{name: "unsupported flag", flags: []string{"--network"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := embeddedOptions(tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if err == nil && !tt.check(options) {
+				t.Errorf("unexpected options: %+v", options)
+			}
+		})
+	}
+}
+
+func TestNewBuilder(t *testing.T) {
+	if _, err := NewBuilder(EngineEmbedded); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewBuilder("docker"); err == nil {
+		t.Errorf("expected error for unknown engine")
+	}
+}
+
+func TestValidateBuildFlags(t *testing.T) {
+	if err := ValidateBuildFlags(EngineEmbedded, nil); err != nil {
+		t.Errorf("expected the default flags to be supported, got: %v", err)
+	}
+	if err := ValidateBuildFlags(EngineEmbedded, []string{"--network"}); err == nil {
+		t.Errorf("expected error for unsupported flag")
+	}
+	if err := ValidateBuildFlags(EngineExec, []string{"--network"}); err != nil {
+		t.Errorf("expected flags of the exec engine not to be validated, got: %v", err)
+	}
+}
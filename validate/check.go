@@ -0,0 +1,136 @@
+package validate
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// Kustomization is the context of the resources passed to a Check
+type Kustomization struct {
+	// Path is the directory of the kustomization
+	Path string
+	// File is the kustomization file within Path
+	File string
+	// Role is the position of the kustomization in the reference graph, one of: root,
+	// base, component. It is empty if the graph is unknown.
+	Role string
+	// Resources are all resources rendered by the kustomization
+	Resources []k8s.Resource
+}
+
+// Check validates the rendered resources of a kustomization. Checks are run for
+// every resource and may be run concurrently for different kustomizations.
+//
+// Organisation-specific checks are added by registering them with Register in a
+// wrapper binary before executing the root command.
+type Check interface {
+	// Name identifies the check. It is the rule of its findings unless they set their own.
+	Name() string
+	// Check returns the findings of the resource rendered by the kustomization
+	Check(kustomization Kustomization, resource k8s.Resource) Resources
+}
+
+// Registry contains checks identified by their name
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+	names  map[string]bool
+}
+
+// DefaultRegistry contains the checks registered with Register, it is run for every kustomization
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+// Register adds the check to the registry. It fails if a check with the same name is registered.
+func (r *Registry) Register(check Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[check.Name()] {
+		return fmt.Errorf("check %s is already registered", check.Name())
+	}
+	r.names[check.Name()] = true
+	r.checks = append(r.checks, check)
+	return nil
+}
+
+// Checks returns the registered checks in the order they were registered
+func (r *Registry) Checks() []Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Check(nil), r.checks...)
+}
+
+// Register adds the check to the DefaultRegistry. It panics if a check with the same
+// name is registered, as it is meant to be called from init functions.
+func Register(check Check) {
+	if err := DefaultRegistry.Register(check); err != nil {
+		panic(err)
+	}
+}
+
+// NewFinding creates a finding with error severity for the field at the path of the
+// resource, located in the rendered output and the source files of the resource.
+// Custom checks return findings without field path for the resource as a whole.
+func NewFinding(resource k8s.Resource, path k8s.FieldPath, message string) Resource {
+	if len(path) == 0 {
+		return Resource{Resource: resource, Severity: SeverityError, Message: message}
+	}
+	finding := newFieldFinding(resource, newSourceLocator(resource), path, message)
+	finding.Severity = SeverityError
+	return finding
+}
+
+// Run runs the checks for every resource of the kustomization. Findings without
+// rule are attributed to the check reporting them, findings without severity are errors.
+func Run(kustomization Kustomization, checks []Check) Resources {
+	var findings Resources
+	for _, check := range checks {
+		for _, resource := range kustomization.Resources {
+			for _, finding := range check.Check(kustomization, resource) {
+				if finding.Rule == "" {
+					finding.Rule = check.Name()
+				}
+				if finding.Severity == "" {
+					finding.Severity = SeverityError
+				}
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// RuleCheck is the Check of a rule, matching its literal, glob, regex or field path pattern
+type RuleCheck struct {
+	Rule
+}
+
+// RuleChecks returns the checks of the rules
+func RuleChecks(rules []Rule) []Check {
+	checks := make([]Check, 0, len(rules))
+	for _, rule := range rules {
+		checks = append(checks, RuleCheck{rule})
+	}
+	return checks
+}
+
+func (c RuleCheck) Name() string {
+	return c.ID
+}
+
+func (c RuleCheck) Check(_ Kustomization, resource k8s.Resource) Resources {
+	findings := validateContent(resource, c.Rule.Check)
+	for i := range findings {
+		findings[i].Rule = c.ID
+		findings[i].Description = c.Description
+		findings[i].Severity = c.Severity
+		findings[i].Remediation = c.Remediation
+	}
+	return findings
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// roleCheck reports every resource of a base kustomization, it sets the rule of its findings only for pods
+type roleCheck struct{}
+
+func (roleCheck) Name() string {
+	return "no-bases"
+}
+
+func (roleCheck) Check(k Kustomization, resource k8s.Resource) Resources {
+	if k.Role != "base" {
+		return nil
+	}
+	finding := Resource{Resource: resource, Severity: SeverityWarning, Message: "rendered by base " + k.Path}
+	if resource.Kind == "Pod" {
+		finding.Rule = "no-base-pods"
+	}
+	return Resources{finding}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(roleCheck{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(RuleCheck{Rule{ID: "PATCH_ME", Check: "PATCH_ME"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(roleCheck{}); err == nil {
+		t.Errorf("expected error registering a check twice")
+	}
+	var got []string
+	for _, check := range registry.Checks() {
+		got = append(got, check.Name())
+	}
+	if want := []string{"no-bases", "PATCH_ME"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected checks %v, got: %v", want, got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	resources := []k8s.Resource{
+		{ApiVersion: "v1", Kind: "Pod", Name: "my-app", FileContent: stdoutExample},
+		{ApiVersion: "v1", Kind: "ConfigMap", Name: "config", FileContent: stdoutExample3},
+	}
+	checks := append(RuleChecks(CheckRules([]string{"PATCH_ME"})), roleCheck{})
+
+	tests := []struct {
+		name string
+		role string
+		want []string
+	}{
+		{name: "root", role: "root", want: []string{"PATCH_ME my-app"}},
+		{name: "base", role: "base", want: []string{"PATCH_ME my-app", "no-base-pods my-app", "no-bases config"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := Kustomization{Path: "base", File: "base/kustomization.yaml", Role: tt.role, Resources: resources}
+			var got []string
+			for _, finding := range Run(k, checks) {
+				got = append(got, finding.Rule+" "+finding.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected findings %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewFinding(t *testing.T) {
+	resource := k8s.ParseKustomizeOutput(stdoutExample, "example", "", "")[0]
+	finding := NewFinding(resource, k8s.FieldPath{"metadata", "labels", "app"}, "label must not be a placeholder")
+	if finding.Severity != SeverityError || finding.FieldPath != "metadata.labels.app" || finding.LineNumber != 6 {
+		t.Errorf("expected error finding in line 6 of metadata.labels.app, got: %+v", finding)
+	}
+	if want := "validation failed: metadata.labels.app: label must not be a placeholder in line 6 for resource v1/Pod/<none>/my-app"; finding.Error() != want {
+		t.Errorf("expected error %q, got: %q", want, finding.Error())
+	}
+
+	finding = NewFinding(resource, nil, "pods must be managed by a controller")
+	if want := "validation failed: pods must be managed by a controller for resource v1/Pod/<none>/my-app"; finding.Error() != want {
+		t.Errorf("expected error %q, got: %q", want, finding.Error())
+	}
+}
@@ -8,11 +8,13 @@
 //   - Default (literal): Simple substring matching, e.g., "PATCH_ME"
 //   - Glob: Wildcard matching, e.g., "glob:PATCH_*"
 //   - Regex: Regular expression, e.g., "regex:\bPATCH_ME\b"
+//   - Field path: Any of the above applied to the values of the fields matching
+//     a path, e.g., "path:spec.template.spec.containers[*].image=regex::latest$"
 //
 // Example usage:
 //
-//	carrier := Carrier{Path: "/path", Stdout: renderedManifest}
-//	errors := ValidateContent(carrier, []string{"PATCH_ME", "glob:TODO*", "regex:\\blatest\\b"})
+//	resources := k8s.ParseKustomizeOutput(renderedManifest, "/path", "", "")
+//	errors := ValidateContent(resources, []string{"PATCH_ME", "glob:TODO*", "regex:\\blatest\\b"})
 //	for _, err := range errors {
 //	    fmt.Print(err.FormatError(true)) // verbose mode shows context
 //	}
@@ -21,6 +23,7 @@ package validate
 import (
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
@@ -30,22 +33,39 @@ import (
 
 type Resource struct {
 	k8s.Resource
+	// Rule is the ID of the rule reporting the finding
+	Rule string
+	// Description of the rule, set for named rules
+	Description string
+	// Remediation describes how to fix the finding
+	Remediation string
 	// Pattern the resource matched
 	Pattern string
+	// FieldPath of the field the finding refers to, e.g. spec.replicas
+	FieldPath string
+	// Message describing the finding, set for findings not caused by a pattern
+	Message string
+	// Severity of the finding, findings without severity are errors
+	Severity Severity
 	// LineNumber the pattern was found on
 	LineNumber int
 	// MatchedLine the pattern was found in
 	MatchedLine string
 	// Context surrounding the matched line
 	Context []string
+	// SourceFile is the file in the repository that introduced the match
+	SourceFile string
+	// SourceLine is the line within SourceFile, or 0 if unknown
+	SourceLine int
 }
 
 type Resources []Resource
 
+// Error returns the combined error of all findings with error severity
 func (ve Resources) Error() error {
 	var msgs []string
 	for _, rsc := range ve {
-		if rsc.isError() {
+		if rsc.isError() && rsc.Severity.AtLeast(SeverityError) {
 			msgs = append(msgs, rsc.Error())
 		}
 	}
@@ -55,17 +75,19 @@ func (ve Resources) Error() error {
 	return nil
 }
 
-func (r Resources) Find(apiVersion, kind, namespace, name string) *Resource {
+// Find returns all findings for the resource with the given identity
+func (r Resources) Find(apiVersion, kind, namespace, name string) Resources {
+	var found Resources
 	for _, res := range r {
 		if res.ApiVersion == apiVersion && res.Kind == kind && res.Namespace == namespace && res.Name == name {
-			return &res
+			found = append(found, res)
 		}
 	}
-	return &Resource{}
+	return found
 }
 
 func (e *Resource) isError() bool {
-	return e != nil && e.Pattern != ""
+	return e != nil && (e.Pattern != "" || e.Message != "")
 }
 
 // Error implements the error interface
@@ -73,17 +95,66 @@ func (e *Resource) Error() string {
 	if !e.isError() {
 		return ""
 	}
-	return fmt.Sprintf("validation failed: found '%s' in line %d for resource %s/%s/%s/%s", e.Pattern, e.LineNumber, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+	msg := "validation failed"
+	if e.Description != "" {
+		// named rules are identified by their ID
+		msg += fmt.Sprintf(" [%s]", e.Rule)
+	}
+	switch {
+	case e.Pattern != "" && e.FieldPath != "":
+		msg += fmt.Sprintf(": found '%s' in field %s in line %d for resource %s/%s/%s/%s", e.Pattern, e.FieldPath, e.LineNumber, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+	case e.Pattern != "":
+		msg += fmt.Sprintf(": found '%s' in line %d for resource %s/%s/%s/%s", e.Pattern, e.LineNumber, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+	case e.FieldPath == "":
+		msg += fmt.Sprintf(": %s for resource %s/%s/%s/%s", e.Message, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+	default:
+		msg += fmt.Sprintf(": %s: %s in line %d for resource %s/%s/%s/%s", e.FieldPath, e.Message, e.LineNumber, e.ApiVersion, e.Kind, e.Namespace, e.Name)
+	}
+	if e.SourceFile != "" {
+		msg += fmt.Sprintf(" introduced by %s", e.source())
+	}
+	return msg
+}
+
+// source returns the source file and line of the match
+func (e *Resource) source() string {
+	if e.SourceLine > 0 {
+		return fmt.Sprintf("%s:%d", e.SourceFile, e.SourceLine)
+	}
+	return e.SourceFile
+}
+
+// Msg formats the finding as a single line colored by its severity
+func (e *Resource) Msg() string {
+	return printf(e.Severity.reason(), e.Error())
 }
 
 // FormatError formats the validation error for display
 func (e *Resource) FormatError(verbose bool) string {
 	var output strings.Builder
 
-	output.WriteString(Errorf("Content validation failed for apiVersion %s, kind %s, namespace %s, name %s", e.ApiVersion, e.Kind, e.Namespace, e.Name))
-	output.WriteString(fmt.Sprintf("\tPattern: %s\n", e.Pattern))
+	output.WriteString(printf(e.Severity.reason(), fmt.Sprintf("Content validation failed for apiVersion %s, kind %s, namespace %s, name %s", e.ApiVersion, e.Kind, e.Namespace, e.Name)))
+	if e.Description != "" {
+		output.WriteString(fmt.Sprintf("\tRule: %s\n", e.Rule))
+		output.WriteString(fmt.Sprintf("\tDescription: %s\n", e.Description))
+	}
+	if e.Pattern != "" {
+		output.WriteString(fmt.Sprintf("\tPattern: %s\n", e.Pattern))
+	}
+	if e.FieldPath != "" {
+		output.WriteString(fmt.Sprintf("\tField: %s\n", e.FieldPath))
+	}
+	if e.Message != "" {
+		output.WriteString(fmt.Sprintf("\tMessage: %s\n", e.Message))
+	}
 	output.WriteString(fmt.Sprintf("\tLine: %d\n", e.LineNumber))
 	output.WriteString(fmt.Sprintf("\tMatch: %s\n", strings.TrimSpace(e.MatchedLine)))
+	if e.SourceFile != "" {
+		output.WriteString(fmt.Sprintf("\tSource: %s\n", e.source()))
+	}
+	if e.Remediation != "" {
+		output.WriteString(fmt.Sprintf("\tRemediation: %s\n", e.Remediation))
+	}
 
 	// In verbose mode, show context
 	if verbose && len(e.Context) > 0 {
@@ -107,59 +178,145 @@ func (e *Resource) FormatError(verbose bool) string {
 	return output.String()
 }
 
-// ValidateContent validates the rendered kustomize output against multiple check patterns
+// ValidateContent validates the rendered kustomize output against multiple check patterns.
+// Every matching line is reported as a separate finding.
 func ValidateContent(resources []k8s.Resource, checks []string) Resources {
-	var errors Resources
-	for _, check := range checks {
-		for _, resource := range resources {
-			if err := validateContent(resource, check); err != nil {
-				errors = append(errors, *err)
-			}
-		}
-	}
-	return errors
+	return ValidateRules(resources, CheckRules(checks))
 }
 
 // validateContent is a helper function for individual content validation
-// Returns a Resource if a match is found, nil otherwise
+// Returns a Resource for every matching line, nil if nothing matches
 // Supports:
 //   - literal substring matching (default)
 //   - glob pattern matching (prefix with "glob:")
 //   - regex pattern matching (prefix with "regex:")
-func validateContent(resource k8s.Resource, check string) *Resource {
-	var pattern string
-	var matchFunc func(line string) bool
-
-	switch {
-	case strings.HasPrefix(check, "glob:"):
-		pattern = strings.TrimPrefix(check, "glob:")
-		matchFunc = createGlobMatcher(pattern)
-	case strings.HasPrefix(check, "regex:"):
-		pattern = strings.TrimPrefix(check, "regex:")
-		matchFunc = createRegexMatcher(pattern)
-	default:
-		// Default: literal substring matching
-		pattern = check
-		matchFunc = createLiteralMatcher(pattern)
+//   - field path scoped matching (prefix with "path:", see validateFieldContent)
+func validateContent(resource k8s.Resource, check string) Resources {
+	if strings.HasPrefix(check, pathPrefix) {
+		return validateFieldContent(resource, check)
 	}
+	pattern, matchFunc := createMatcher(check)
 
+	var matches Resources
+	locator := newSourceLocator(resource)
 	// Split output into lines for line-by-line matching
 	lines := strings.Split(resource.FileContent, "\n")
 	// Check each line
 	for lineNum, line := range lines {
-		if matchFunc(line) {
-			// Extract context (±2 lines)
-			context := extractContext(lines, lineNum, 2)
-			return &Resource{
-				Resource:    resource,
-				Pattern:     pattern,
-				LineNumber:  lineNum + 1,
-				MatchedLine: line,
-				Context:     context,
+		if !matchFunc(line) {
+			continue
+		}
+		// Extract context (±2 lines)
+		context := extractContext(lines, lineNum, 2)
+		// prefer the source line equal to the rendered line, so repeated matches
+		// are located at their own line rather than the first match of the pattern
+		sourceFile, sourceLine := locator.locate(createLiteralMatcher(strings.TrimSpace(line)), matchFunc)
+		matches = append(matches, Resource{
+			Resource:    resource,
+			Pattern:     pattern,
+			LineNumber:  lineNum + 1,
+			MatchedLine: line,
+			Context:     context,
+			SourceFile:  sourceFile,
+			SourceLine:  sourceLine,
+		})
+	}
+	return matches
+}
+
+// sourceLocator locates the source files and lines that introduced matches into
+// the rendered resource. Every
+// source line is attributed to a single match, so repeated matches of a pattern
+// are located at subsequent lines.
+type sourceLocator struct {
+	resource k8s.Resource
+	// candidates are the files that may have introduced a match, in search order
+	candidates []string
+	// contents are the lines of the candidates, nil if a file cannot be read
+	contents map[string][]string
+	// claimed contains the lines of each file already attributed to a match
+	claimed map[string]map[int]bool
+}
+
+// newSourceLocator creates a locator for the source files of the resource. Patches
+// are searched before the origin of the resource, latest first, as they override
+// the values of the resources they are applied to.
+func newSourceLocator(resource k8s.Resource) *sourceLocator {
+	l := &sourceLocator{
+		resource: resource,
+		contents: map[string][]string{},
+		claimed:  map[string]map[int]bool{},
+	}
+	for i := len(resource.Patches) - 1; i >= 0; i-- {
+		l.candidates = append(l.candidates, resource.Patches[i])
+	}
+	if resource.Origin != "" {
+		l.candidates = append(l.candidates, resource.Origin)
+	}
+	return l
+}
+
+// locate returns the first unclaimed source line matching the matchers, which are
+// tried in order. If all matching lines are claimed already, the first one is
+// returned. If no file contains a match, the origin is returned without a line.
+func (l *sourceLocator) locate(matchFuncs ...func(line string) bool) (string, int) {
+	for _, matchFunc := range matchFuncs {
+		firstFile, firstLine := "", 0
+		for _, file := range l.candidates {
+			for lineNum, line := range l.lines(file) {
+				if !matchFunc(line) {
+					continue
+				}
+				if firstFile == "" {
+					firstFile, firstLine = file, lineNum+1
+				}
+				if !l.claimed[file][lineNum+1] {
+					l.claim(file, lineNum+1)
+					return file, lineNum + 1
+				}
 			}
 		}
+		if firstFile != "" {
+			return firstFile, firstLine
+		}
+	}
+	return l.resource.Origin, 0
+}
+
+// lines returns the lines of the file, reading it on first use
+func (l *sourceLocator) lines(file string) []string {
+	if lines, ok := l.contents[file]; ok {
+		return lines
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		l.contents[file] = nil
+		return nil
+	}
+	l.contents[file] = strings.Split(string(content), "\n")
+	return l.contents[file]
+}
+
+func (l *sourceLocator) claim(file string, line int) {
+	if l.claimed[file] == nil {
+		l.claimed[file] = map[int]bool{}
+	}
+	l.claimed[file][line] = true
+}
+
+// createMatcher creates the matcher function of a check and returns the pattern without prefix
+func createMatcher(check string) (string, func(string) bool) {
+	switch {
+	case strings.HasPrefix(check, "glob:"):
+		pattern := strings.TrimPrefix(check, "glob:")
+		return pattern, createGlobMatcher(pattern)
+	case strings.HasPrefix(check, "regex:"):
+		pattern := strings.TrimPrefix(check, "regex:")
+		return pattern, createRegexMatcher(pattern)
+	default:
+		// Default: literal substring matching
+		return check, createLiteralMatcher(check)
 	}
-	return nil
 }
 
 // createLiteralMatcher creates a matcher function for literal substring matching
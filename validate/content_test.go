@@ -1,6 +1,10 @@
 package validate
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/redhat-consulting-services/kustomize-validator/k8s"
@@ -624,9 +628,243 @@ func Test_validateContent(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := validateContent(tt.args.resource, tt.args.check)
-			if (result != nil) != tt.wantErr {
+			if (len(result) > 0) != tt.wantErr {
 				t.Errorf("expected error: %v, got: %v", tt.wantErr, result)
 			}
 		})
 	}
 }
+
+func Test_validateContent_allMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		check     string
+		wantLines []int
+	}{
+		{name: "single match", content: stdoutExample, check: "PATCH_ME", wantLines: []int{6}},
+		{name: "repeated literal", content: stdoutExample7, check: "PATCH_ME_APP", wantLines: []int{8, 12}},
+		{name: "repeated glob", content: stdoutExample4, check: "glob:CHANGE_ME*", wantLines: []int{6, 7, 8}},
+		{name: "several patterns on different lines", content: stdoutExample6, check: "regex:TODO|FIXME", wantLines: []int{6, 7}},
+		{name: "no match", content: stdoutExample, check: "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "test", FileContent: tt.content}
+			var got []int
+			for _, finding := range validateContent(resource, tt.check) {
+				got = append(got, finding.LineNumber)
+			}
+			if !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("expected matches in lines %v, got: %v", tt.wantLines, got)
+			}
+		})
+	}
+}
+
+func Test_validateContent_sourceLines(t *testing.T) {
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "deployment.yaml")
+	if err := os.WriteFile(origin, []byte(stdoutExample7), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resource := k8s.Resource{ApiVersion: "apps/v1", Kind: "Deployment", Name: "my-deployment", FileContent: stdoutExample7, Origin: origin}
+
+	var got []int
+	for _, finding := range validateContent(resource, "PATCH_ME_APP") {
+		if finding.SourceFile != origin {
+			t.Errorf("expected source %s, got: %s", origin, finding.SourceFile)
+		}
+		got = append(got, finding.SourceLine)
+	}
+	if want := []int{8, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected source lines %v, got: %v", want, got)
+	}
+}
+
+func TestResources_Find(t *testing.T) {
+	pod := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "my-app", Namespace: "default"}
+	service := k8s.Resource{ApiVersion: "v1", Kind: "Service", Name: "my-app", Namespace: "default"}
+	findings := Resources{
+		{Resource: pod, Pattern: "PATCH_ME", LineNumber: 3},
+		{Resource: service, Pattern: "PATCH_ME", LineNumber: 4},
+		{Resource: pod, Pattern: "TODO", LineNumber: 7},
+	}
+
+	got := findings.Find("v1", "Pod", "default", "my-app")
+	if len(got) != 2 || got[0].LineNumber != 3 || got[1].LineNumber != 7 {
+		t.Errorf("expected both findings of the pod, got: %+v", got)
+	}
+	if got := findings.Find("v1", "ConfigMap", "default", "my-app"); len(got) != 0 {
+		t.Errorf("expected no findings, got: %+v", got)
+	}
+}
+
+func Test_validateFieldContent(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    release: latest-rollout
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx:latest
+      - name: sidecar
+        image: envoy:1.30
+      - name: proxy
+        image: haproxy:latest
+`
+	resources := k8s.ParseKustomizeOutput(content, "example", "", "")
+	if len(resources) != 1 {
+		t.Fatalf("expected a single resource, got: %d", len(resources))
+	}
+
+	tests := []struct {
+		name      string
+		check     string
+		wantPaths []string
+		wantLines []int
+	}{
+		{
+			name:      "regex on image",
+			check:     "path:spec.template.spec.containers[*].image=regex::latest$",
+			wantPaths: []string{"spec.template.spec.containers[0].image", "spec.template.spec.containers[2].image"},
+			wantLines: []int{13, 17},
+		},
+		{
+			name:      "label not matched by image check",
+			check:     "path:spec.template.spec.containers[*].image=latest-rollout",
+			wantPaths: nil,
+		},
+		{
+			name:      "glob on label",
+			check:     "path:metadata.labels.*=glob:latest*",
+			wantPaths: []string{"metadata.labels.release"},
+			wantLines: []int{6},
+		},
+		{
+			name:      "integer value",
+			check:     "path:spec.replicas=regex:^1$",
+			wantPaths: []string{"spec.replicas"},
+			wantLines: []int{8},
+		},
+		{
+			name:      "map values are not matched",
+			check:     "path:spec.template=nginx",
+			wantPaths: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			var lines []int
+			for _, finding := range validateContent(resources[0], tt.check) {
+				paths = append(paths, finding.FieldPath)
+				lines = append(lines, finding.LineNumber)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("expected fields %v, got: %v", tt.wantPaths, paths)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("expected lines %v, got: %v", tt.wantLines, lines)
+			}
+		})
+	}
+}
+
+func TestValidateChecks(t *testing.T) {
+	tests := []struct {
+		check   string
+		wantErr bool
+	}{
+		{check: "PATCH_ME"},
+		{check: "regex:[invalid"},
+		{check: "path:spec.containers[*].image=regex::latest$"},
+		{check: "path:spec.containers[*].image", wantErr: true},
+		{check: "path:spec.containers[*.image=latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.check, func(t *testing.T) {
+			if err := ValidateChecks([]string{tt.check}); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	resource := k8s.Resource{ApiVersion: "v1", Kind: "Pod", Name: "my-app", FileContent: stdoutExample}
+	rules := append(CheckRules([]string{"PATCH_ME"}), Rule{
+		ID:          "no-placeholder-labels",
+		Description: "Labels must not contain placeholders",
+		Severity:    SeverityWarning,
+		Remediation: "Set the label in the overlay",
+		Check:       "regex:app: PATCH",
+	})
+
+	findings := ValidateRules([]k8s.Resource{resource}, rules)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got: %d", len(findings))
+	}
+	if findings[0].Rule != "PATCH_ME" || findings[0].Severity != SeverityError || findings[0].Description != "" {
+		t.Errorf("expected plain check finding with error severity, got: %+v", findings[0])
+	}
+	named := findings[1]
+	if named.Rule != "no-placeholder-labels" || named.Severity != SeverityWarning || named.Remediation != "Set the label in the overlay" {
+		t.Errorf("expected finding of the named rule, got: %+v", named)
+	}
+	if got := named.Error(); !strings.HasPrefix(got, "validation failed [no-placeholder-labels]: found 'app: PATCH'") {
+		t.Errorf("expected error identifying the rule, got: %s", got)
+	}
+	formatted := named.FormatError(false)
+	for _, want := range []string{"WARNING", "Rule: no-placeholder-labels", "Description: Labels must not contain placeholders", "Remediation: Set the label in the overlay"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("expected formatted error to contain %q, got: %s", want, formatted)
+		}
+	}
+	if err := findings.Error(); err == nil || strings.Contains(err.Error(), "no-placeholder-labels") {
+		t.Errorf("expected only the error severity finding in the error, got: %v", err)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: conventions
+  annotations:
+    kustomize-validator.io/ignore: "PATCH_ME, no-latest-tag"
+data:
+  placeholders: Use PATCH_ME for values set in overlays
+  image: nginx:latest
+  todo: TODO
+`
+	resources := k8s.ParseKustomizeOutput(content, "example", "", "")
+	rules := append(CheckRules([]string{"PATCH_ME", "TODO"}), Rule{
+		ID:          "no-latest-tag",
+		Description: "Images must be pinned to a version",
+		Check:       "path:data.image=regex::latest$",
+	})
+
+	remaining, suppressed := Suppress(ValidateRules(resources, rules))
+	var got []string
+	for _, finding := range remaining {
+		got = append(got, finding.Rule)
+	}
+	if want := []string{"TODO"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected remaining findings %v, got: %v", want, got)
+	}
+	got = nil
+	for _, finding := range suppressed {
+		got = append(got, finding.Rule)
+	}
+	// the annotation itself contains the suppressed pattern
+	if want := []string{"PATCH_ME", "PATCH_ME", "no-latest-tag"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected suppressed findings %v, got: %v", want, got)
+	}
+}
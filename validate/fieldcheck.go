@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// pathPrefix prefixes checks scoped to a field path, e.g.
+// path:spec.template.spec.containers[*].image=regex::latest$
+const pathPrefix = "path:"
+
+// parseFieldCheck splits a field path check into the field path pattern and the
+// check applied to the values of the matching fields
+func parseFieldCheck(check string) (k8s.FieldPath, string, error) {
+	expression, valueCheck, ok := strings.Cut(strings.TrimPrefix(check, pathPrefix), "=")
+	if !ok || valueCheck == "" {
+		return nil, "", fmt.Errorf("invalid check %q: expected path:<field path>=<pattern>", check)
+	}
+	path, err := k8s.ParseFieldPath(expression)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid check %q: %w", check, err)
+	}
+	return path, valueCheck, nil
+}
+
+// ValidateChecks returns an error if any of the checks cannot be parsed
+func ValidateChecks(checks []string) error {
+	for _, check := range checks {
+		if !strings.HasPrefix(check, pathPrefix) {
+			continue
+		}
+		if _, _, err := parseFieldCheck(check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldContent validates the values of the fields matching the path of a
+// field path check. The values are taken from the parsed object rather than the raw
+// lines, so only the scoped fields are matched. Fields with a map or list value are
+// not matched, use a path selecting their items instead.
+func validateFieldContent(resource k8s.Resource, check string) Resources {
+	path, valueCheck, err := parseFieldCheck(check)
+	if err != nil {
+		// invalid checks are rejected by ValidateChecks before the validation
+		return nil
+	}
+	pattern, matchFunc := createMatcher(valueCheck)
+
+	var matches Resources
+	locator := newSourceLocator(resource)
+	for _, field := range resource.Fields(path) {
+		value, ok := scalarString(field.Value)
+		if !ok || !matchFunc(value) {
+			continue
+		}
+		finding := newFieldFinding(resource, locator, field.Path, "")
+		finding.Pattern = pattern
+		matches = append(matches, finding)
+	}
+	return matches
+}
+
+// scalarString formats a scalar value as it is written in YAML
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case map[string]any, []any:
+		return "", false
+	case nil:
+		return "null", true
+	case string:
+		return v, true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
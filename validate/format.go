@@ -1,17 +1,33 @@
 package validate
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+)
 
 type Carrier struct {
-	Path   string
+	Path string
+	// File is the kustomization file within Path
+	File   string
 	Stdout string
 	Stderr string
 	Err    error
+	// TimedOut is set if the build was killed after exceeding its timeout
+	TimedOut bool
+	// OriginRoot is the directory the origin annotations in Stdout are relative to
+	OriginRoot string
 }
 
 func (c Carrier) Msg(errorOnly bool, verbose bool) string {
 	msg := ""
-	if c.Err != nil {
+	if c.TimedOut {
+		msg += Errorf("Timeout while executing kustomize in path: %s, %s", c.Path, c.Err)
+		if verbose {
+			msg += Unknownf("==> Stderr (%s):\n%s", c.Path, c.Stderr)
+		}
+	} else if c.Err != nil {
 		msg += Errorf("Error while executing kustomize in path: %s, %s", c.Path, c.Err)
 		if verbose {
 			msg += Unknownf("==> Stdout (%s):\n%s", c.Path, c.Stdout)
@@ -29,6 +45,12 @@ func (c Carrier) Msg(errorOnly bool, verbose bool) string {
 	return msg
 }
 
+// IsToolError reports whether the build failed because the kustomize
+// binary could not be executed rather than because of the kustomization
+func (c Carrier) IsToolError() bool {
+	return errors.Is(c.Err, exec.ErrNotFound)
+}
+
 // ------
 
 type Color string
@@ -96,3 +118,11 @@ func Unknownf(format string, a ...interface{}) string {
 func ColorF(color Color, format string, a ...any) string {
 	return fmt.Sprintf("%s%s%s", color, fmt.Sprintf(format, a...), ColorNC)
 }
+
+// colorRegex matches the ANSI color escape sequences used by the Color constants
+var colorRegex = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// StripColor removes ANSI color escape sequences from the given string
+func StripColor(s string) string {
+	return colorRegex.ReplaceAllString(s, "")
+}
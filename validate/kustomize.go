@@ -1,52 +1,351 @@
 package validate
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
-	"os/exec"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-func KustomizeBuild(basePath string) <-chan Carrier {
-	return walkPathAndFindKustomizationFileAnRun(basePath)
+// DefaultBuildFlags are passed to kustomize build if no build flags are configured
+var DefaultBuildFlags = []string{"--enable-helm", "--enable-alpha-plugins"}
+
+// BuildOptions configures how kustomizations are discovered and built
+type BuildOptions struct {
+	// Concurrency is the maximum number of kustomize builds running in parallel.
+	// Values below 1 default to the number of CPUs.
+	Concurrency int
+	// Timeout is the maximum duration of a single kustomize build.
+	// A zero value disables the timeout.
+	Timeout time.Duration
+	// BuildFlags are passed to kustomize build. If nil, DefaultBuildFlags are used.
+	BuildFlags []string
+	// Skip is called for every directory below the base path during discovery.
+	// If it returns true, the directory is not descended into.
+	Skip func(dir string) bool
+	// Select is called for every directory containing a kustomization file during
+	// discovery. If it returns false, the kustomization is not built. If nil, all
+	// kustomizations are built.
+	Select func(dir string) bool
+	// Builder renders the kustomizations. If nil, the kustomize binary is executed.
+	Builder Builder
+}
+
+// KustomizationFileNames are the names of kustomization files, in the order kustomize looks for them
+var KustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml"}
+
+// KustomizeBuild discovers all kustomization files below basePath and builds them
+// using a bounded pool of workers. The returned channel receives one Carrier per
+// discovered kustomization and is closed once every build has reported.
+func KustomizeBuild(ctx context.Context, basePath string, opts BuildOptions) (<-chan Carrier, error) {
+	return walkPathAndFindKustomizationFileAnRun(ctx, basePath, opts)
+}
+
+// FindKustomizations returns the paths of all kustomization files below basePath
+// and the directories of the kustomizations skipped by the Skip and Select options.
+// Kustomizations below skipped directories are neither visited nor counted.
+func FindKustomizations(basePath string, opts BuildOptions) ([]string, []string, error) {
+	return findKustomizations(basePath, opts.Skip, opts.Select)
+}
+
+// FindAllKustomizations returns the paths of all kustomization files below basePath
+// not skipped by the Skip option and the directories of the skipped kustomizations.
+// The Select option is not applied, see SelectKustomizations.
+func FindAllKustomizations(basePath string, opts BuildOptions) ([]string, []string, error) {
+	return findKustomizations(basePath, opts.Skip, nil)
+}
+
+// SelectKustomizations splits the kustomization files into the ones selected by the
+// Select option and the directories of the others. Discovery only applies the Skip
+// option, so the references between kustomizations are known before selecting.
+func SelectKustomizations(files []string, opts BuildOptions) ([]string, []string) {
+	if opts.Select == nil {
+		return files, nil
+	}
+	var selected, skipped []string
+	for _, file := range files {
+		if dir := filepath.Dir(file); opts.Select(dir) {
+			selected = append(selected, file)
+		} else {
+			skipped = append(skipped, dir)
+		}
+	}
+	return selected, skipped
+}
+
+// BuildKustomizations builds the given kustomization files using a bounded pool
+// of workers. The returned channel receives one Carrier per kustomization and is
+// closed once every build has reported.
+func BuildKustomizations(ctx context.Context, files []string, opts BuildOptions) <-chan Carrier {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+
+	// all jobs are known upfront, so the job queue can be filled and closed right away
+	jobs := make(chan string, len(files))
+	for _, file := range files {
+		jobs <- file
+	}
+	close(jobs)
+
+	msgChan := make(chan Carrier)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				msgChan <- executeKustomize(ctx, file, opts)
+			}
+		}()
+	}
+
+	// close the channel once every worker is done, so consumers can range over it
+	go func() {
+		wg.Wait()
+		close(msgChan)
+	}()
+	return msgChan
 }
 
 // walkPathAndFindKustomizationFileAnRun walks the given path and finds the kustomization files
 // and runs the kustomize build command on the directory containing the kustomization file.
 // It returns a channel that will contain the messages from the kustomize build command.
-func walkPathAndFindKustomizationFileAnRun(basePath string) <-chan Carrier {
-	msgChan := make(chan Carrier)
-	filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+func walkPathAndFindKustomizationFileAnRun(ctx context.Context, basePath string, opts BuildOptions) (<-chan Carrier, error) {
+	files, _, err := findKustomizations(basePath, opts.Skip, opts.Select)
+	if err != nil {
+		return nil, err
+	}
+	return BuildKustomizations(ctx, files, opts), nil
+}
+
+// findKustomizations walks the given path and returns the paths of all kustomization
+// files and the directories of skipped kustomizations. Directories for which skip
+// returns true are not descended into, kustomizations for which selectDir returns
+// false are skipped.
+func findKustomizations(basePath string, skip, selectDir func(dir string) bool) ([]string, []string, error) {
+	var files, skipped []string
+	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		if d.IsDir() {
+			if skip != nil && path != basePath && skip(path) {
+				if hasKustomization(path) {
+					skipped = append(skipped, path)
+				}
+				return filepath.SkipDir
+			}
 			// is a directory so we can skip it
 			return nil
 		}
-		if d.Name() != "kustomization.yaml" && d.Name() != "kustomization.yml" {
+		if !isKustomizationFile(d.Name()) {
 			// if the file is not a kustomization file we can skip it
 			return nil
 		}
-		go func(path string) {
-			msgChan <- executeKustomize(path)
-		}(filepath.Dir(path))
+		if selectDir != nil && !selectDir(filepath.Dir(path)) {
+			skipped = append(skipped, filepath.Dir(path))
+			return nil
+		}
+		files = append(files, path)
 		return nil
 	})
-	return msgChan
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find kustomization files in %s: %w", basePath, err)
+	}
+	return files, skipped, nil
+}
+
+// isKustomizationFile reports whether the file name is the name of a kustomization file
+func isKustomizationFile(name string) bool {
+	for _, fileName := range KustomizationFileNames {
+		if name == fileName {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKustomization reports whether the directory contains a kustomization file
+func hasKustomization(dir string) bool {
+	return KustomizationFile(dir) != ""
 }
 
-// executeKustomize runs the kustomize build command on the given path
-// and returns the stdout and stderr writers and an error if any
-// occurred during the execution.
-func executeKustomize(path string) Carrier {
-	stderrWriter := bytes.NewBuffer([]byte{})
-	stdoutWriter := bytes.NewBuffer([]byte{})
-	cmd := exec.Command("kustomize", "build", "--enable-helm", "--enable-alpha-plugins", path)
-	cmd.Stderr = stderrWriter
-	cmd.Stdout = stdoutWriter
-	err := cmd.Run()
+// KustomizationFile returns the path of the kustomization file in the directory,
+// or an empty string if the directory does not contain one
+func KustomizationFile(dir string) string {
+	for _, fileName := range KustomizationFileNames {
+		file := filepath.Join(dir, fileName)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+	return ""
+}
+
+// executeKustomize runs the kustomize build command on the directory of the
+// given kustomization file and returns the stdout and stderr writers and an error if any
+// occurred during the execution. If the build takes longer than the
+// given timeout, it is killed and the returned Carrier is marked as timed out.
+//
+// The kustomization is built through a temporary wrapper kustomization enabling
+// origin and transformer annotations, so findings can be mapped to their source
+// files. If the wrapped build fails, the wrapper is removed from the reported errors.
+// Only if the errors do not come from the kustomization, e.g. because the wrapper
+// itself failed, the kustomization is built again directly. The direct build has a
+// timeout of its own, the failed wrapped build must not use up its time.
+func executeKustomize(ctx context.Context, file string, opts BuildOptions) Carrier {
+	path := filepath.Dir(file)
+	wrapper, err := originWrapper(file)
+	if err == nil {
+		defer os.RemoveAll(wrapper)
+		carrier := runKustomize(ctx, wrapper, opts)
+		if carrier.Err == nil || carrier.TimedOut || unwrapErrors(&carrier, path) {
+			carrier.Path = path
+			carrier.File = file
+			carrier.OriginRoot = wrapper
+			return carrier
+		}
+	}
+
+	carrier := runKustomize(ctx, path, opts)
+	carrier.File = file
+	return carrier
+}
+
+// runKustomize runs the kustomize build on the given directory using the configured builder,
+// killing it once it exceeds the timeout
+func runKustomize(ctx context.Context, path string, opts BuildOptions) Carrier {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	builder := opts.Builder
+	if builder == nil {
+		builder = ExecBuilder{}
+	}
+	flags := opts.BuildFlags
+	if flags == nil {
+		flags = DefaultBuildFlags
+	}
+	stdout, stderr, err := builder.Build(ctx, path, flags)
+
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if timedOut {
+		err = fmt.Errorf("build exceeded the timeout of %s", opts.Timeout)
+	}
 	return Carrier{
-		Path:   path,
-		Stdout: stdoutWriter.String(),
-		Stderr: stderrWriter.String(),
-		Err:    err,
+		Path:     path,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		Err:      err,
+		TimedOut: timedOut,
+	}
+}
+
+// unwrapErrors removes the wrapper from the errors of the failed wrapped build of the
+// kustomization in dir, so they read like the errors of a direct build. kustomize
+// reports the errors of the kustomization after the path it recursed into. It returns
+// false if the errors do not contain that path.
+func unwrapErrors(carrier *Carrier, dir string) bool {
+	target, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	marker := fmt.Sprintf("recursed accumulation of path '%s': ", target)
+	unwrap := func(msg string) (string, bool) {
+		i := strings.Index(msg, marker)
+		if i < 0 {
+			return "", false
+		}
+		prefix, rest := msg[:i], msg[i+len(marker):]
+		body := strings.TrimRight(rest, "\n")
+		newline := rest[len(body):]
+		if strings.HasSuffix(prefix, `"`) {
+			// the errors of components are quoted
+			body = strings.TrimSuffix(body, `"`)
+		}
+		// keep the output preceding the error, e.g. warnings
+		head := ""
+		if j := strings.LastIndex(prefix, "Error: "); j >= 0 {
+			head = prefix[:j+len("Error: ")]
+		}
+		return head + body + newline, true
+	}
+
+	// the exec builder reports the errors on stderr, the embedded builder in the error
+	if carrier.Stderr != "" {
+		stderr, ok := unwrap(carrier.Stderr)
+		if ok {
+			carrier.Stderr = stderr
+		}
+		return ok
+	}
+	msg, ok := unwrap(carrier.Err.Error())
+	if ok {
+		carrier.Err = errors.New(msg)
+	}
+	return ok
+}
+
+// originWrapper creates a temporary directory with a kustomization that includes the
+// given kustomization and enables the origin and transformer annotations. Build metadata
+// is inherited by included kustomizations, so every rendered resource is annotated.
+// The caller is responsible for removing the returned directory.
+func originWrapper(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	var target struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal(content, &target); err != nil {
+		return "", err
+	}
+
+	targetDir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "kustomize-validator-")
+	if err != nil {
+		return "", err
+	}
+	// kustomize does not accept absolute paths for directories
+	rel, err := filepath.Rel(dir, targetDir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	wrapper := map[string]any{
+		"apiVersion":    "kustomize.config.k8s.io/v1beta1",
+		"kind":          "Kustomization",
+		"buildMetadata": []string{"originAnnotations", "transformerAnnotations"},
+	}
+	if target.Kind == "Component" {
+		wrapper["components"] = []string{filepath.ToSlash(rel)}
+	} else {
+		wrapper["resources"] = []string{filepath.ToSlash(rel)}
+	}
+	out, err := yaml.Marshal(wrapper)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "kustomization.yaml"), out, 0o600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
 	}
+	return dir, nil
 }
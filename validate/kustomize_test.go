@@ -0,0 +1,184 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_walkPathAndFindKustomizationFileAnRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "single worker", concurrency: 1},
+		{name: "more workers than kustomizations", concurrency: 10},
+		{name: "default concurrency", concurrency: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgChan, err := walkPathAndFindKustomizationFileAnRun(context.Background(), "../_tests", BuildOptions{Concurrency: tt.concurrency})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// the channel must be closed once every kustomization reported
+			count := 0
+			for range msgChan {
+				count++
+			}
+			if count != 3 {
+				t.Errorf("expected 3 messages, got: %d", count)
+			}
+		})
+	}
+}
+
+func Test_findKustomizations(t *testing.T) {
+	paths, _, err := findKustomizations("../_tests", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("expected 3 kustomizations, got: %v", paths)
+	}
+
+	if _, _, err := findKustomizations("../_tests/does-not-exist", nil, nil); err == nil {
+		t.Errorf("expected error for missing path")
+	}
+}
+
+func Test_findKustomizationsSkip(t *testing.T) {
+	visited := 0
+	paths, skipped, err := findKustomizations("../_tests", func(dir string) bool {
+		visited++
+		return filepath.Base(dir) == "app2"
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("expected 2 kustomizations, got: %v", paths)
+	}
+	if visited != 3 {
+		t.Errorf("expected skip to be called for 3 directories, got: %d", visited)
+	}
+	if len(skipped) != 1 || filepath.Base(skipped[0]) != "app2" {
+		t.Errorf("expected app2 to be skipped, got: %v", skipped)
+	}
+}
+
+func Test_findKustomizationsSelect(t *testing.T) {
+	paths, skipped, err := findKustomizations("../_tests", nil, func(dir string) bool {
+		return filepath.Base(dir) == "app1"
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(filepath.Dir(paths[0])) != "app1" {
+		t.Errorf("expected only app1 to be selected, got: %v", paths)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("expected 2 skipped kustomizations, got: %v", skipped)
+	}
+}
+
+func TestSelectKustomizations(t *testing.T) {
+	files, _, err := FindAllKustomizations("../_tests", BuildOptions{Select: func(string) bool { return false }})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected Select to be ignored by discovery, got: %v", files)
+	}
+	selected, skipped := SelectKustomizations(files, BuildOptions{Select: func(dir string) bool {
+		return filepath.Base(dir) == "app1"
+	}})
+	if len(selected) != 1 || filepath.Base(filepath.Dir(selected[0])) != "app1" {
+		t.Errorf("expected only app1 to be selected, got: %v", selected)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("expected 2 skipped kustomizations, got: %v", skipped)
+	}
+}
+
+// slowBuilder fails every build after the given delay
+type slowBuilder struct {
+	delay time.Duration
+}
+
+func (b slowBuilder) Build(ctx context.Context, _ string, _ []string) (string, string, error) {
+	select {
+	case <-time.After(b.delay):
+		return "", "", errors.New("missing resource")
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	}
+}
+
+func Test_executeKustomizeTimeout(t *testing.T) {
+	opts := BuildOptions{Timeout: 20 * time.Millisecond, Builder: slowBuilder{delay: time.Second}}
+	carrier := executeKustomize(context.Background(), "../_tests/app1/kustomization.yaml", opts)
+	if !carrier.TimedOut {
+		t.Errorf("expected the build to time out")
+	}
+	if carrier.Err == nil || !strings.Contains(carrier.Err.Error(), "build exceeded the timeout") {
+		t.Errorf("expected timeout error, got: %v", carrier.Err)
+	}
+}
+
+func Test_executeKustomizeFallbackTimeout(t *testing.T) {
+	// the wrapped and the direct build each take 60ms, together longer than the timeout
+	opts := BuildOptions{Timeout: 100 * time.Millisecond, Builder: slowBuilder{delay: 60 * time.Millisecond}}
+	carrier := executeKustomize(context.Background(), "../_tests/app1/kustomization.yaml", opts)
+	if carrier.TimedOut || carrier.Err == nil || carrier.Err.Error() != "missing resource" {
+		t.Errorf("expected the build error of the direct build, got: %v (timed out: %v)", carrier.Err, carrier.TimedOut)
+	}
+}
+
+// countingBuilder counts the builds
+type countingBuilder struct {
+	Builder
+	builds int
+}
+
+func (b *countingBuilder) Build(ctx context.Context, dir string, flags []string) (string, string, error) {
+	b.builds++
+	return b.Builder.Build(ctx, dir, flags)
+}
+
+func Test_executeKustomizeErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		kustomization string
+	}{
+		{name: "missing resource", kustomization: "resources:\n  - missing.yaml\n"},
+		{name: "missing patch", kustomization: "patches:\n  - path: missing.yaml\n"},
+		{name: "component", kustomization: "apiVersion: kustomize.config.k8s.io/v1alpha1\nkind: Component\nresources:\n  - missing.yaml\n"},
+	}
+	for _, builder := range []Builder{ExecBuilder{}, EmbeddedBuilder{}} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%T %s", builder, tt.name), func(t *testing.T) {
+				dir := t.TempDir()
+				file := filepath.Join(dir, "kustomization.yaml")
+				if err := os.WriteFile(file, []byte(tt.kustomization), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				counting := &countingBuilder{Builder: builder}
+				carrier := executeKustomize(context.Background(), file, BuildOptions{Builder: counting})
+				if counting.builds != 1 {
+					t.Errorf("expected a single build, got: %d", counting.builds)
+				}
+				// the errors must read like the errors of the direct build
+				direct := runKustomize(context.Background(), dir, BuildOptions{Builder: builder})
+				if carrier.Err == nil || carrier.Err.Error() != direct.Err.Error() || carrier.Stderr != direct.Stderr {
+					t.Errorf("expected the errors of the direct build %v %q, got: %v %q", direct.Err, direct.Stderr, carrier.Err, carrier.Stderr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package validate
+
+import "github.com/redhat-consulting-services/kustomize-validator/k8s"
+
+const (
+	// RuleSchema is the rule of findings violating the schema of their kind
+	RuleSchema = "schema"
+	// RuleUnknownKind is the rule of findings on resources of kinds without schema
+	RuleUnknownKind = "unknown-kind"
+)
+
+// Rule is a named content check
+type Rule struct {
+	// ID identifies the rule in findings and reports
+	ID string
+	// Description explains what the rule detects, empty for plain checks
+	Description string
+	// Severity of the findings of the rule
+	Severity Severity
+	// Remediation describes how to fix the findings of the rule
+	Remediation string
+	// Check is the check pattern of the rule, see ValidateContent
+	Check string
+}
+
+// CheckRules converts plain check patterns into rules with error severity, identified by their pattern
+func CheckRules(checks []string) []Rule {
+	rules := make([]Rule, 0, len(checks))
+	for _, check := range checks {
+		rules = append(rules, Rule{ID: check, Severity: SeverityError, Check: check})
+	}
+	return rules
+}
+
+// ValidateRules validates the rendered resources against the rules. Every
+// finding carries the ID, severity, description and remediation of its rule.
+func ValidateRules(resources []k8s.Resource, rules []Rule) Resources {
+	return Run(Kustomization{Resources: resources}, RuleChecks(rules))
+}
@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
+)
+
+// ValidateSchema validates the rendered resources against the schemas of their kinds.
+// Every field violating the schema is reported as a finding. Resources of kinds
+// without a schema are reported with the given severity, or skipped if it is empty.
+func ValidateSchema(resources []k8s.Resource, validator *schema.Validator, unknownKind Severity) Resources {
+	return Run(Kustomization{Resources: resources}, []Check{SchemaCheck{Validator: validator, UnknownKind: unknownKind}})
+}
+
+// SchemaCheck is the Check validating resources against the schemas of their kinds, see ValidateSchema
+type SchemaCheck struct {
+	Validator *schema.Validator
+	// UnknownKind is the severity of resources of kinds without schema, they are skipped if it is empty
+	UnknownKind Severity
+}
+
+func (c SchemaCheck) Name() string {
+	return RuleSchema
+}
+
+func (c SchemaCheck) Check(_ Kustomization, resource k8s.Resource) Resources {
+	var errors Resources
+	gvk := schema.ParseGroupVersionKind(resource.ApiVersion, resource.Kind)
+	fieldErrors, known := c.Validator.Validate(gvk, resource.Object)
+	locator := newSourceLocator(resource)
+	if !known {
+		if c.UnknownKind != "" {
+			finding := newFieldFinding(resource, locator, k8s.FieldPath{"kind"}, fmt.Sprintf("no schema found for kind %s", gvk))
+			finding.Rule = RuleUnknownKind
+			finding.Severity = c.UnknownKind
+			errors = append(errors, finding)
+		}
+		return errors
+	}
+	for _, fieldError := range fieldErrors {
+		finding := newFieldFinding(resource, locator, fieldError.Path, fieldError.Message)
+		finding.Rule = RuleSchema
+		finding.Severity = SeverityError
+		errors = append(errors, finding)
+	}
+	return errors
+}
+
+// newFieldFinding creates a finding for a field of the resource, located in the
+// source files of the resource with the given locator
+func newFieldFinding(resource k8s.Resource, locator *sourceLocator, path k8s.FieldPath, message string) Resource {
+	finding := Resource{
+		Resource:  resource,
+		FieldPath: path.String(),
+		Message:   message,
+	}
+
+	lines := strings.Split(resource.FileContent, "\n")
+	if line := resource.LineOf(path); line > 0 && line <= len(lines) {
+		finding.LineNumber = line
+		finding.MatchedLine = lines[line-1]
+		finding.Context = extractContext(lines, line-1, 2)
+		finding.SourceFile, finding.SourceLine = locator.locate(
+			createLiteralMatcher(strings.TrimSpace(finding.MatchedLine)),
+			fieldMatcher(path, finding.MatchedLine),
+		)
+	}
+	return finding
+}
+
+// fieldMatcher creates a matcher function finding the line of a field in a source file.
+// Fields are matched by their key, as the formatting of the value in the source file
+// may differ from the rendered output. List items are matched by their rendered line.
+func fieldMatcher(path k8s.FieldPath, renderedLine string) func(string) bool {
+	if len(path) == 0 || strings.HasPrefix(path[len(path)-1], "[") {
+		return createLiteralMatcher(strings.TrimSpace(renderedLine))
+	}
+	re := regexp.MustCompile(`^\s*(- )?["']?` + regexp.QuoteMeta(path[len(path)-1]) + `["']?\s*:`)
+	return re.MatchString
+}
+
+// AddCRDs registers the schemas of all CustomResourceDefinitions among the rendered resources.
+// Invalid CustomResourceDefinitions are skipped and returned as findings of the schema rule.
+func AddCRDs(resources []k8s.Resource, validator *schema.Validator) Resources {
+	var findings Resources
+	for _, resource := range resources {
+		if !schema.IsCRD(resource.ApiVersion, resource.Kind) {
+			continue
+		}
+		if err := validator.AddCRD(resource.Object); err != nil {
+			finding := NewFinding(resource, nil, err.Error())
+			finding.Rule = RuleSchema
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
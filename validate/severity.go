@@ -0,0 +1,55 @@
+package validate
+
+import "fmt"
+
+// Severity of a finding
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// severityRanks orders the severities, higher is more severe
+var severityRanks = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// ParseSeverity parses a severity, an empty string is parsed as SeverityError
+func ParseSeverity(s string) (Severity, error) {
+	if s == "" {
+		return SeverityError, nil
+	}
+	if _, ok := severityRanks[Severity(s)]; !ok {
+		return "", fmt.Errorf("unsupported severity %q, expected one of: info, warning, error", s)
+	}
+	return Severity(s), nil
+}
+
+// AtLeast reports whether the severity is at least as severe as the given one
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// rank returns the rank of the severity, findings without severity are errors
+func (s Severity) rank() int {
+	if s == "" {
+		return severityRanks[SeverityError]
+	}
+	return severityRanks[s]
+}
+
+// reason returns the output reason of the severity
+func (s Severity) reason() reason {
+	switch s {
+	case SeverityInfo:
+		return ReasonInfo
+	case SeverityWarning:
+		return ReasonWarning
+	default:
+		return ReasonError
+	}
+}
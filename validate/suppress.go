@@ -0,0 +1,46 @@
+package validate
+
+import (
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// IgnoreAnnotation suppresses findings on the annotated resource. Its value is a
+// comma separated list of rule IDs or check patterns, e.g. "PATCH_ME,no-latest-tag".
+const IgnoreAnnotation = "kustomize-validator.io/ignore"
+
+// Suppress separates the findings suppressed by the IgnoreAnnotation of their
+// resource from the remaining findings
+func Suppress(findings Resources) (remaining Resources, suppressed Resources) {
+	for _, finding := range findings {
+		if finding.suppressed() {
+			suppressed = append(suppressed, finding)
+			continue
+		}
+		remaining = append(remaining, finding)
+	}
+	return remaining, suppressed
+}
+
+// suppressed reports whether the rule or pattern of the finding is listed in the
+// IgnoreAnnotation of its resource
+func (e *Resource) suppressed() bool {
+	for _, ignored := range ignoredRules(e.Resource) {
+		if ignored == e.Rule || (e.Pattern != "" && ignored == e.Pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredRules returns the rule IDs and check patterns listed in the IgnoreAnnotation of the resource
+func ignoredRules(resource k8s.Resource) []string {
+	var ignored []string
+	for _, entry := range strings.Split(resource.Annotation(IgnoreAnnotation), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			ignored = append(ignored, entry)
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,334 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+// WorkloadRulesAll enables all workload rules in SelectWorkloadChecks
+const WorkloadRulesAll = "all"
+
+// podTemplate is the pod template of a rendered workload
+type podTemplate struct {
+	kind string
+	// path is the path of the pod spec within the workload
+	path k8s.FieldPath
+	spec map[string]any
+}
+
+// container is a container of a pod template
+type container struct {
+	name string
+	path k8s.FieldPath
+	spec map[string]any
+	// init reports whether the container is an init container
+	init bool
+}
+
+// violation is a field of a pod template violating a workload rule
+type violation struct {
+	path    k8s.FieldPath
+	message string
+}
+
+// WorkloadCheck is a built-in best practice rule for the pod templates of Deployments,
+// StatefulSets, DaemonSets, Jobs and CronJobs. Its findings carry the field path of the
+// violating field, or of the missing field.
+type WorkloadCheck struct {
+	Rule
+	check func(pod podTemplate) []violation
+}
+
+// workloadChecks are the built-in workload rules, in the order they are reported
+var workloadChecks = []WorkloadCheck{
+	{
+		Rule: Rule{
+			ID:          "resource-requests",
+			Description: "Containers must request CPU and memory",
+			Severity:    SeverityWarning,
+			Remediation: "Set resources.requests.cpu and resources.requests.memory of the container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			return missingResources(c, "requests", "cpu", "memory")
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "resource-limits",
+			Description: "Containers must limit their memory",
+			Severity:    SeverityWarning,
+			Remediation: "Set resources.limits.memory of the container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			return missingResources(c, "limits", "memory")
+		}),
+	},
+	{
+		// separate from resource-limits, CPU limits throttle containers even on idle nodes
+		Rule: Rule{
+			ID:          "cpu-limits",
+			Description: "Containers must limit their CPU",
+			Severity:    SeverityWarning,
+			Remediation: "Set resources.limits.cpu of the container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			return missingResources(c, "limits", "cpu")
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "image-tag",
+			Description: "Images must be pinned to a version",
+			Severity:    SeverityWarning,
+			Remediation: "Use an immutable image tag other than latest, or a digest",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			image, _ := c.spec["image"].(string)
+			if image == "" {
+				return nil
+			}
+			switch tag := imageTag(image); tag {
+			case "":
+				return []violation{{c.path.Child("image"), fmt.Sprintf("image %s of container %s has no tag", image, c.name)}}
+			case "latest":
+				return []violation{{c.path.Child("image"), fmt.Sprintf("image %s of container %s uses the latest tag", image, c.name)}}
+			}
+			return nil
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "liveness-probe",
+			Description: "Containers of long-running workloads must have a liveness probe",
+			Severity:    SeverityWarning,
+			Remediation: "Set livenessProbe of the container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			return missingProbe(pod, c, "livenessProbe")
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "readiness-probe",
+			Description: "Containers of long-running workloads must have a readiness probe",
+			Severity:    SeverityWarning,
+			Remediation: "Set readinessProbe of the container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			return missingProbe(pod, c, "readinessProbe")
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "privileged",
+			Description: "Containers must not run privileged",
+			Severity:    SeverityError,
+			Remediation: "Remove securityContext.privileged or grant the required capabilities only",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			if privileged, _ := k8s.Lookup(c.spec, "securityContext", "privileged").(bool); privileged {
+				return []violation{{c.path.Child("securityContext").Child("privileged"), fmt.Sprintf("container %s runs privileged", c.name)}}
+			}
+			return nil
+		}),
+	},
+	{
+		Rule: Rule{
+			ID:          "host-network",
+			Description: "Pods must not use the network namespace of the host",
+			Severity:    SeverityError,
+			Remediation: "Remove hostNetwork from the pod spec",
+		},
+		check: func(pod podTemplate) []violation {
+			if hostNetwork, _ := pod.spec["hostNetwork"].(bool); hostNetwork {
+				return []violation{{pod.path.Child("hostNetwork"), "the pod uses the network of the host"}}
+			}
+			return nil
+		},
+	},
+	{
+		Rule: Rule{
+			ID:          "host-path",
+			Description: "Pods must not mount directories of the host",
+			Severity:    SeverityError,
+			Remediation: "Replace the hostPath volume, e.g., with an emptyDir or a persistent volume claim",
+		},
+		check: func(pod podTemplate) []violation {
+			var violations []violation
+			volumes, _ := pod.spec["volumes"].([]any)
+			for i, volume := range volumes {
+				v, _ := volume.(map[string]any)
+				if _, ok := v["hostPath"]; ok {
+					violations = append(violations, violation{pod.path.Child("volumes").Index(i).Child("hostPath"), fmt.Sprintf("volume %v mounts a directory of the host", v["name"])})
+				}
+			}
+			return violations
+		},
+	},
+	{
+		Rule: Rule{
+			ID:          "run-as-non-root",
+			Description: "Containers must run as non-root user",
+			Severity:    SeverityWarning,
+			Remediation: "Set securityContext.runAsNonRoot: true in the pod or container",
+		},
+		check: containerCheck(func(pod podTemplate, c container) []violation {
+			// the security context of the container takes precedence over the one of the pod
+			nonRoot, ok := k8s.Lookup(c.spec, "securityContext", "runAsNonRoot").(bool)
+			if !ok {
+				nonRoot, _ = k8s.Lookup(pod.spec, "securityContext", "runAsNonRoot").(bool)
+			}
+			if nonRoot {
+				return nil
+			}
+			return []violation{{c.path.Child("securityContext").Child("runAsNonRoot"), fmt.Sprintf("container %s may run as root", c.name)}}
+		}),
+	},
+}
+
+// WorkloadChecks returns all built-in workload rules
+func WorkloadChecks() []WorkloadCheck {
+	return append([]WorkloadCheck(nil), workloadChecks...)
+}
+
+// SelectWorkloadChecks returns the workload rules with the given IDs, in the order of
+// WorkloadChecks. WorkloadRulesAll selects all rules.
+func SelectWorkloadChecks(ids []string) ([]Check, error) {
+	selected := map[string]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var checks []Check
+	for _, check := range workloadChecks {
+		if selected[check.ID] || selected[WorkloadRulesAll] {
+			checks = append(checks, check)
+		}
+		delete(selected, check.ID)
+	}
+	delete(selected, WorkloadRulesAll)
+	if len(selected) > 0 {
+		unknown := make([]string, 0, len(selected))
+		for id := range selected {
+			unknown = append(unknown, id)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown rule(s) %s, expected one of: %s, %s", strings.Join(unknown, ", "), WorkloadRulesAll, strings.Join(WorkloadRuleIDs(), ", "))
+	}
+	return checks, nil
+}
+
+// WorkloadRuleIDs returns the IDs of the built-in workload rules
+func WorkloadRuleIDs() []string {
+	ids := make([]string, 0, len(workloadChecks))
+	for _, check := range workloadChecks {
+		ids = append(ids, check.ID)
+	}
+	return ids
+}
+
+func (c WorkloadCheck) Name() string {
+	return c.ID
+}
+
+func (c WorkloadCheck) Check(_ Kustomization, resource k8s.Resource) Resources {
+	pod, ok := workloadPodTemplate(resource)
+	if !ok {
+		return nil
+	}
+	var findings Resources
+	locator := newSourceLocator(resource)
+	for _, v := range c.check(pod) {
+		finding := newFieldFinding(resource, locator, v.path, v.message)
+		finding.Rule = c.ID
+		finding.Description = c.Description
+		finding.Severity = c.Severity
+		finding.Remediation = c.Remediation
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// workloadPodTemplate returns the pod template of a Deployment, StatefulSet, DaemonSet,
+// Job or CronJob. It reports false for all other resources.
+func workloadPodTemplate(resource k8s.Resource) (podTemplate, bool) {
+	group, _, _ := strings.Cut(resource.ApiVersion, "/")
+	var path k8s.FieldPath
+	switch {
+	case group == "apps" && (resource.Kind == "Deployment" || resource.Kind == "StatefulSet" || resource.Kind == "DaemonSet"),
+		group == "batch" && resource.Kind == "Job":
+		path = k8s.FieldPath{"spec", "template", "spec"}
+	case group == "batch" && resource.Kind == "CronJob":
+		path = k8s.FieldPath{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return podTemplate{}, false
+	}
+	spec, ok := k8s.Lookup(resource.Object, path...).(map[string]any)
+	if !ok {
+		return podTemplate{}, false
+	}
+	return podTemplate{kind: resource.Kind, path: path, spec: spec}, true
+}
+
+// containerCheck applies the check to the init containers and containers of the pod template
+func containerCheck(check func(pod podTemplate, c container) []violation) func(pod podTemplate) []violation {
+	return func(pod podTemplate) []violation {
+		var violations []violation
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _ := pod.spec[field].([]any)
+			for i, item := range containers {
+				spec, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				name, _ := spec["name"].(string)
+				c := container{name: name, path: pod.path.Child(field).Index(i), spec: spec, init: field == "initContainers"}
+				violations = append(violations, check(pod, c)...)
+			}
+		}
+		return violations
+	}
+}
+
+// missingResources reports the resources missing in the requests or limits of the container
+func missingResources(c container, field string, resources ...string) []violation {
+	values, _ := k8s.Lookup(c.spec, "resources", field).(map[string]any)
+	if len(values) == 0 {
+		return []violation{{c.path.Child("resources").Child(field), fmt.Sprintf("container %s has no resource %s", c.name, field)}}
+	}
+	var violations []violation
+	for _, resource := range resources {
+		if _, ok := values[resource]; !ok {
+			violations = append(violations, violation{c.path.Child("resources").Child(field).Child(resource), fmt.Sprintf("container %s has no %s %s", c.name, resource, strings.TrimSuffix(field, "s"))})
+		}
+	}
+	return violations
+}
+
+// missingProbe reports the probe missing in a container of a long-running workload.
+// Init containers and the containers of Jobs and CronJobs run to completion and are not probed.
+func missingProbe(pod podTemplate, c container, probe string) []violation {
+	if c.init || pod.kind == "Job" || pod.kind == "CronJob" {
+		return nil
+	}
+	if _, ok := c.spec[probe]; ok {
+		return nil
+	}
+	return []violation{{c.path.Child(probe), fmt.Sprintf("container %s has no %s", c.name, probe)}}
+}
+
+// imageTag returns the tag of the image reference, "@" followed by the digest for
+// references pinned to a digest, or an empty string if the reference has neither
+func imageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[i:]
+	}
+	// the registry host may contain a port, the tag follows the last path segment
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
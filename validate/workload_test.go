@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+)
+
+const workloadExample = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      securityContext:
+        runAsNonRoot: true
+      initContainers:
+        - name: init
+          image: busybox
+          resources:
+            requests:
+              cpu: 10m
+              memory: 16Mi
+            limits:
+              memory: 16Mi
+      containers:
+        - name: app
+          image: registry.example.com:5000/app:latest
+          securityContext:
+            privileged: true
+            runAsNonRoot: false
+          livenessProbe:
+            httpGet:
+              path: /healthz
+          resources:
+            requests:
+              memory: 64Mi
+        - name: sidecar
+          image: registry.example.com/sidecar@sha256:0123
+          readinessProbe:
+            tcpSocket:
+              port: 8080
+          livenessProbe:
+            tcpSocket:
+              port: 8080
+          resources:
+            requests:
+              cpu: 10m
+              memory: 16Mi
+            limits:
+              memory: 16Mi
+      volumes:
+        - name: config
+          configMap:
+            name: config
+        - name: docker
+          hostPath:
+            path: /var/run/docker.sock
+`
+
+const cronJobExample = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: cleanup:1.0
+`
+
+func TestWorkloadCheck_Check(t *testing.T) {
+	deployment := k8s.ParseKustomizeOutput(workloadExample, "example", "", "")[0]
+	cronJob := k8s.ParseKustomizeOutput(cronJobExample, "example", "", "")[0]
+	service := k8s.Resource{ApiVersion: "v1", Kind: "Service", Name: "my-app", FileContent: stdoutExample2}
+
+	tests := []struct {
+		rule     string
+		resource k8s.Resource
+		want     []string
+	}{
+		{rule: "resource-requests", resource: deployment, want: []string{"spec.template.spec.containers[0].resources.requests.cpu"}},
+		{rule: "resource-limits", resource: deployment, want: []string{"spec.template.spec.containers[0].resources.limits"}},
+		{rule: "cpu-limits", resource: deployment, want: []string{"spec.template.spec.initContainers[0].resources.limits.cpu", "spec.template.spec.containers[0].resources.limits", "spec.template.spec.containers[1].resources.limits.cpu"}},
+		{rule: "image-tag", resource: deployment, want: []string{"spec.template.spec.initContainers[0].image", "spec.template.spec.containers[0].image"}},
+		{rule: "liveness-probe", resource: deployment, want: nil},
+		{rule: "readiness-probe", resource: deployment, want: []string{"spec.template.spec.containers[0].readinessProbe"}},
+		{rule: "privileged", resource: deployment, want: []string{"spec.template.spec.containers[0].securityContext.privileged"}},
+		{rule: "host-network", resource: deployment, want: []string{"spec.template.spec.hostNetwork"}},
+		{rule: "host-path", resource: deployment, want: []string{"spec.template.spec.volumes[1].hostPath"}},
+		{rule: "run-as-non-root", resource: deployment, want: []string{"spec.template.spec.containers[0].securityContext.runAsNonRoot"}},
+		{rule: "readiness-probe", resource: cronJob, want: nil},
+		{rule: "run-as-non-root", resource: cronJob, want: []string{"spec.jobTemplate.spec.template.spec.containers[0].securityContext.runAsNonRoot"}},
+		{rule: "image-tag", resource: service, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.resource.Kind, func(t *testing.T) {
+			checks, err := SelectWorkloadChecks([]string{tt.rule})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, finding := range checks[0].Check(Kustomization{}, tt.resource) {
+				if finding.Rule != tt.rule || finding.Description == "" || finding.LineNumber == 0 {
+					t.Errorf("expected located finding of rule %s, got: %+v", tt.rule, finding)
+				}
+				got = append(got, finding.FieldPath)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected findings at %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectWorkloadChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		want    int
+		wantErr bool
+	}{
+		{name: "none", want: 0},
+		{name: "all", ids: []string{WorkloadRulesAll}, want: len(WorkloadRuleIDs())},
+		{name: "some", ids: []string{"privileged", "host-path"}, want: 2},
+		{name: "unknown", ids: []string{"privileged", "no-root"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := SelectWorkloadChecks(tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+			if len(checks) != tt.want {
+				t.Errorf("expected %d checks, got: %d", tt.want, len(checks))
+			}
+		})
+	}
+}
+
+func Test_imageTag(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                             "",
+		"nginx:1.27":                        "1.27",
+		"registry.example.com:5000/nginx":   "",
+		"registry.example.com:5000/app:v1":  "v1",
+		"nginx@sha256:0123":                 "@sha256:0123",
+		"ghcr.io/org/app:latest@sha256:abc": "@sha256:abc",
+	}
+	for image, want := range tests {
+		if got := imageTag(image); got != want {
+			t.Errorf("expected tag %q of %s, got: %q", want, image, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/redhat-consulting-services/kustomize-validator/config"
+	"github.com/redhat-consulting-services/kustomize-validator/git"
+	"github.com/redhat-consulting-services/kustomize-validator/graph"
+)
+
+// changedKustomizations splits the kustomization files into the ones affected by the
+// files changed in the git repository of the path since the reference and the directories of the
+// unaffected kustomizations. It fails if the affected kustomizations cannot be
+// determined, e.g. because a kustomization file cannot be parsed or a configuration
+// file changed, the caller validates all kustomizations then.
+func changedKustomizations(ctx context.Context, path, ref string, g *graph.Graph, files []string) ([]string, []string, error) {
+	changed, err := git.ChangedFiles(ctx, path, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range changed {
+		switch filepath.Base(file) {
+		case config.FileName, config.IgnoreFileName:
+			return nil, nil, fmt.Errorf("the configuration file %s changed", file)
+		}
+	}
+	dirs, err := g.Affected(changed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isAffected := map[string]bool{}
+	for _, dir := range dirs {
+		isAffected[dir] = true
+	}
+	var affected, unaffected []string
+	for _, file := range files {
+		if dir := filepath.Dir(file); isAffected[dir] {
+			affected = append(affected, file)
+		} else {
+			unaffected = append(unaffected, dir)
+		}
+	}
+	return affected, unaffected, nil
+}
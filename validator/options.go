@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/redhat-consulting-services/kustomize-validator/policy"
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// Option configures a Validator
+type Option func(*Validator)
+
+// WithPaths adds the paths searched for kustomizations
+func WithPaths(paths ...string) Option {
+	return func(v *Validator) {
+		v.paths = append(v.paths, paths...)
+	}
+}
+
+// WithChecks adds checks run for every kustomization
+func WithChecks(checks ...validate.Check) Option {
+	return func(v *Validator) {
+		v.checks = append(v.checks, checks...)
+	}
+}
+
+// WithChecksFor sets the function returning the additional checks of the kustomization
+// in a directory, e.g., the checks of the overrides of the configuration file
+func WithChecksFor(checksFor func(dir string) []validate.Check) Option {
+	return func(v *Validator) {
+		v.checksFor = checksFor
+	}
+}
+
+// WithSchema validates the rendered resources against the schemas of the validator.
+// The CustomResourceDefinitions rendered by any kustomization are added to it.
+// Resources of kinds without schema are reported with the given severity, or skipped if it is empty.
+func WithSchema(validator *schema.Validator, unknownKind validate.Severity) Option {
+	return func(v *Validator) {
+		v.schema = validator
+		v.unknownKind = unknownKind
+	}
+}
+
+// WithAdmissionPolicies evaluates the ValidatingAdmissionPolicies of the evaluator against the
+// rendered resources. The policies, bindings and params rendered by any kustomization are added to it.
+func WithAdmissionPolicies(admission *policy.Admission) Option {
+	return func(v *Validator) {
+		v.admission = admission
+	}
+}
+
+// WithConcurrency sets the maximum number of kustomize builds running in parallel
+func WithConcurrency(concurrency int) Option {
+	return func(v *Validator) {
+		v.build.Concurrency = concurrency
+	}
+}
+
+// WithTimeout sets the timeout of a single kustomize build, 0 disables the timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(v *Validator) {
+		v.build.Timeout = timeout
+	}
+}
+
+// WithBuildFlags sets the flags passed to kustomize build
+func WithBuildFlags(flags ...string) Option {
+	return func(v *Validator) {
+		v.build.BuildFlags = flags
+	}
+}
+
+// WithBuilder sets the engine building the kustomizations, by default the kustomize binary is executed
+func WithBuilder(builder validate.Builder) Option {
+	return func(v *Validator) {
+		v.build.Builder = builder
+	}
+}
+
+// WithFilter sets the functions selecting the discovered kustomizations, see validate.BuildOptions.
+// Either function may be nil.
+func WithFilter(skip, selectDir func(dir string) bool) Option {
+	return func(v *Validator) {
+		v.build.Skip = skip
+		v.build.Select = selectDir
+	}
+}
+
+// WithLeavesOnly applies the checks only to the kustomizations no other kustomization
+// references. Referenced bases and components are only checked for buildability.
+func WithLeavesOnly(leavesOnly bool) Option {
+	return func(v *Validator) {
+		v.leavesOnly = leavesOnly
+	}
+}
+
+// WithChangedSince validates only the kustomizations affected by the files changed in
+// the git repository since the reference. Unaffected kustomizations are reported as skipped.
+func WithChangedSince(ref string) Option {
+	return func(v *Validator) {
+		v.changedSince = ref
+	}
+}
+
+// WithBaseline excludes the known findings recorded in the baseline loaded from the file
+func WithBaseline(baseline *report.Baseline, file string) Option {
+	return func(v *Validator) {
+		v.baseline = baseline
+		v.baselineFile = file
+	}
+}
+
+// WithReporters adds reporters receiving the report after the run
+func WithReporters(reporters ...Reporter) Option {
+	return func(v *Validator) {
+		v.reporters = append(v.reporters, reporters...)
+	}
+}
@@ -0,0 +1,270 @@
+// Package validator builds and validates all kustomizations below a set of paths.
+// It is the library behind the kustomize-validator command and can be embedded
+// in other Go tools:
+//
+//	v := validator.New(
+//		validator.WithPaths("./overlays"),
+//		validator.WithChecks(validate.RuleChecks(validate.CheckRules([]string{"PATCH_ME"}))...),
+//		validator.WithReporters(validator.ReportWriter(os.Stdout, report.WriteJSON)),
+//	)
+//	r, err := v.Run(ctx)
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/redhat-consulting-services/kustomize-validator/graph"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/policy"
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// Validator builds the kustomizations below its paths and runs its checks on the rendered resources.
+// A Validator runs once: the CustomResourceDefinitions and admission policies rendered during
+// the run are added to its schema validator and admission, and its baseline records the
+// findings it matched. Create a new Validator, with new options, for every run.
+type Validator struct {
+	paths  []string
+	checks []validate.Check
+	// checksFor returns additional checks of the kustomization in a directory, nil if there are none
+	checksFor func(dir string) []validate.Check
+	// schema validates rendered resources against the schemas of their kinds, nil if disabled
+	schema *schema.Validator
+	// unknownKind is the severity of resources without schema, empty if they are not reported
+	unknownKind validate.Severity
+	// admission evaluates the bound ValidatingAdmissionPolicies, nil if disabled
+	admission *policy.Admission
+	build     validate.BuildOptions
+	// leavesOnly exempts kustomizations referenced by other kustomizations from the checks
+	leavesOnly bool
+	// changedSince is the git reference kustomizations must be affected by changes since, empty to validate all
+	changedSince string
+	// baseline contains the known findings, nil if none is used
+	baseline     *report.Baseline
+	baselineFile string
+	reporters    []Reporter
+	// ran reports whether the validator already ran
+	ran bool
+}
+
+// Result is the result of building and validating a single kustomization
+type Result struct {
+	validate.Carrier
+	// Resources are the resources rendered by the kustomization
+	Resources []k8s.Resource
+	// Findings of all checks, without the suppressed and baselined findings
+	Findings validate.Resources
+	// Suppressed are the findings suppressed by resource annotations
+	Suppressed validate.Resources
+	// Baselined are the findings recorded in the baseline
+	Baselined validate.Resources
+	// Node is the kustomization in the reference graph, nil if it is unknown
+	Node *graph.Node
+}
+
+// Report is the result of a validation run
+type Report struct {
+	// Report is the structured report written by the machine-readable formats
+	*report.Report
+	// Results are the results of all kustomizations sorted by their path, in the order of the report
+	Results []Result
+	// Graphs contain the references between the kustomizations below every path
+	Graphs map[string]*graph.Graph
+	// Warnings are the problems of the run that did not prevent the validation
+	Warnings []string
+}
+
+// ToolErrors returns the number of builds that failed because kustomize could not be executed
+func (r *Report) ToolErrors() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.IsToolError() {
+			count++
+		}
+	}
+	return count
+}
+
+// Reporter receives the report of a validation run
+type Reporter interface {
+	Report(r *Report) error
+}
+
+// ReporterFunc is a function implementing Reporter
+type ReporterFunc func(r *Report) error
+
+func (f ReporterFunc) Report(r *Report) error {
+	return f(r)
+}
+
+// ReportWriter returns a reporter writing the structured report in the format of
+// the writer, e.g., report.WriteJSON, report.WriteSARIF or report.WriteJUnit
+func ReportWriter(w io.Writer, write func(io.Writer, *report.Report) error) Reporter {
+	return ReporterFunc(func(r *Report) error {
+		r.Sort()
+		return write(w, r.Report)
+	})
+}
+
+// New creates a validator with the given options. By default it runs only the
+// checks of the validate.DefaultRegistry, with the build options of validate.BuildKustomizations.
+func New(opts ...Option) *Validator {
+	v := &Validator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Run builds and validates all kustomizations below the paths and passes the report to
+// the reporters. Failing builds and findings are part of the report; an error is only
+// returned if the validation could not be run or a reporter failed.
+func (v *Validator) Run(ctx context.Context) (*Report, error) {
+	if len(v.paths) == 0 {
+		return nil, errors.New("no path to validate")
+	}
+	if v.ran {
+		return nil, errors.New("the validator already ran, create a new one for every run")
+	}
+	v.ran = true
+	r := &Report{Report: report.New(strings.Join(v.paths, " ")), Graphs: map[string]*graph.Graph{}}
+	if v.baseline != nil {
+		r.Baseline = v.baselineFile
+	}
+
+	for _, path := range v.paths {
+		if err := v.buildPath(ctx, path, r); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(r.Results, func(i, j int) bool {
+		return r.Results[i].Path < r.Results[j].Path
+	})
+	// the CustomResourceDefinitions rendered by any kustomization must be known before
+	// the custom resources are validated, invalid ones are findings of the kustomization
+	// rendering them
+	invalid := make([]validate.Resources, len(r.Results))
+	if v.schema != nil {
+		for i, result := range r.Results {
+			invalid[i] = validate.AddCRDs(result.Resources, v.schema)
+		}
+	}
+	// likewise, the policies, bindings and params rendered by any kustomization apply to all resources
+	if v.admission != nil {
+		for i, result := range r.Results {
+			for _, resource := range result.Resources {
+				if err := v.admission.AddResource(resource); err != nil {
+					finding := validate.NewFinding(resource, nil, err.Error())
+					finding.Rule = policy.RuleAdmissionPolicy
+					finding.Description = "Rendered ValidatingAdmissionPolicies and their bindings must be valid"
+					invalid[i] = append(invalid[i], finding)
+				}
+			}
+		}
+	}
+
+	for i := range r.Results {
+		result := &r.Results[i]
+		v.check(result, invalid[i])
+		k := report.NewKustomization(result.Carrier, result.Resources, result.Findings)
+		k.Suppressed = report.NewFindings(result.Suppressed)
+		k.Baselined = report.NewFindings(result.Baselined)
+		if node := result.Node; node != nil {
+			k.Role = string(node.Role())
+			k.References = node.Kustomizations()
+			k.ReferencedBy = node.ReferencedBy
+		}
+		r.Add(k)
+	}
+	if v.baseline != nil {
+		// kustomizations missing from a run validating all of them no longer exist
+		r.StaleBaseline = v.baseline.Stale(v.changedSince == "" && v.build.Select == nil)
+	}
+
+	for _, reporter := range v.reporters {
+		if err := reporter.Report(r); err != nil {
+			return r, fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// buildPath discovers and builds the kustomizations below the path and adds their results to the report
+func (v *Validator) buildPath(ctx context.Context, path string, r *Report) error {
+	cwd, _ := os.Getwd()
+	// the graph contains the unselected kustomizations too, so the roles and the
+	// kustomizations affected by changed bases do not depend on --include
+	files, skipped, err := validate.FindAllKustomizations(path, v.build)
+	if err != nil {
+		return err
+	}
+	g := graph.Build(path, files)
+	r.Graphs[path] = g
+	files, unselected := validate.SelectKustomizations(files, v.build)
+	skipped = append(skipped, unselected...)
+	if v.changedSince != "" {
+		affected, unaffected, err := changedKustomizations(ctx, path, v.changedSince, g, files)
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("failed to determine the kustomizations changed since %s, validating all kustomizations: %s", v.changedSince, err))
+		} else {
+			files = affected
+			skipped = append(skipped, unaffected...)
+		}
+	}
+	sort.Strings(skipped)
+	r.Skip(skipped)
+
+	for msg := range validate.BuildKustomizations(ctx, files, v.build) {
+		// all rendered resources from kustomize output
+		resources := k8s.ParseKustomizeOutput(msg.Stdout, msg.Path, msg.OriginRoot, cwd)
+		msg.Stdout = k8s.StripOriginAnnotations(msg.Stdout)
+		r.Results = append(r.Results, Result{Carrier: msg, Resources: resources, Node: g.Node(msg.Path)})
+	}
+	return nil
+}
+
+// check runs the checks on the rendered resources of the result and adds their findings
+// to the given ones. With leaves only, bases are only checked for buildability, their
+// placeholders are replaced by the overlays.
+func (v *Validator) check(result *Result, findings validate.Resources) {
+	node := result.Node
+	if v.leavesOnly && node != nil && !node.IsRoot() {
+		return
+	}
+	kustomization := validate.Kustomization{Path: result.Path, Resources: result.Resources}
+	if node != nil {
+		kustomization.File = node.File
+		kustomization.Role = string(node.Role())
+	}
+	findings = append(findings, validate.Run(kustomization, v.checksOf(result.Path))...)
+	result.Findings, result.Suppressed = validate.Suppress(findings)
+	// known findings of the baseline do not fail the run
+	if v.baseline != nil && result.Err == nil {
+		result.Findings, result.Baselined = v.baseline.Filter(result.Path, result.Findings)
+	}
+}
+
+// checksOf returns the checks run for the kustomization in the given directory: the
+// checks of the validator and of the directory, the schema and admission policy checks
+// if enabled, and the checks of the validate.DefaultRegistry
+func (v *Validator) checksOf(dir string) []validate.Check {
+	checks := append([]validate.Check(nil), v.checks...)
+	if v.checksFor != nil {
+		checks = append(checks, v.checksFor(dir)...)
+	}
+	if v.schema != nil {
+		checks = append(checks, validate.SchemaCheck{Validator: v.schema, UnknownKind: v.unknownKind})
+	}
+	if v.admission != nil {
+		checks = append(checks, v.admission)
+	}
+	return append(checks, validate.DefaultRegistry.Checks()...)
+}
@@ -0,0 +1,207 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/redhat-consulting-services/kustomize-validator/internal/testutil"
+	"github.com/redhat-consulting-services/kustomize-validator/k8s"
+	"github.com/redhat-consulting-services/kustomize-validator/policy"
+	"github.com/redhat-consulting-services/kustomize-validator/report"
+	"github.com/redhat-consulting-services/kustomize-validator/schema"
+	"github.com/redhat-consulting-services/kustomize-validator/validate"
+)
+
+// serviceCheck reports every rendered Service
+type serviceCheck struct{}
+
+func (serviceCheck) Name() string {
+	return "no-services"
+}
+
+func (serviceCheck) Check(_ validate.Kustomization, resource k8s.Resource) validate.Resources {
+	if resource.Kind != "Service" {
+		return nil
+	}
+	return validate.Resources{validate.NewFinding(resource, nil, "services are not allowed")}
+}
+
+func TestValidator_Run(t *testing.T) {
+	var reported *Report
+	buf := &bytes.Buffer{}
+	v := New(
+		WithPaths("../_tests"),
+		WithChecks(validate.RuleChecks(validate.CheckRules([]string{"PATCH_ME"}))...),
+		WithChecksFor(func(dir string) []validate.Check {
+			if strings.HasSuffix(dir, "app3") {
+				return []validate.Check{serviceCheck{}}
+			}
+			return nil
+		}),
+		WithFilter(func(dir string) bool { return strings.HasSuffix(dir, "app2") }, nil),
+		WithConcurrency(2),
+		WithBuilder(validate.EmbeddedBuilder{}),
+		WithReporters(
+			ReporterFunc(func(r *Report) error {
+				reported = r
+				return nil
+			}),
+			ReportWriter(buf, report.WriteJSON),
+		),
+	)
+
+	r, err := v.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reported != r {
+		t.Errorf("expected the report to be passed to the reporters")
+	}
+	if want := (report.Summary{Total: 2, Error: 2, Findings: 2, Skipped: 1}); r.Summary != want {
+		t.Errorf("expected summary %+v, got: %+v", want, r.Summary)
+	}
+
+	var got []string
+	for _, result := range r.Results {
+		for _, finding := range result.Findings {
+			got = append(got, result.Path+" "+finding.Rule+" "+finding.Kind)
+		}
+	}
+	if want := []string{"../_tests/app1 PATCH_ME Deployment", "../_tests/app3 no-services Service"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected findings %v, got: %v", want, got)
+	}
+	if r.Results[0].Node == nil || r.Kustomizations[0].Role != "root" {
+		t.Errorf("expected the graph node of the kustomization, got: %+v", r.Kustomizations[0])
+	}
+
+	var written report.Report
+	if err := json.Unmarshal(buf.Bytes(), &written); err != nil {
+		t.Fatalf("expected JSON report, got error: %v", err)
+	}
+	if written.Summary != r.Summary {
+		t.Errorf("expected written summary %+v, got: %+v", r.Summary, written.Summary)
+	}
+}
+
+func TestValidator_RunWithoutPath(t *testing.T) {
+	if _, err := New().Run(context.Background()); err == nil {
+		t.Errorf("expected error without path")
+	}
+}
+
+func TestValidator_RunTwice(t *testing.T) {
+	v := New(WithPaths("../_tests"), WithBuilder(validate.EmbeddedBuilder{}))
+	if _, err := v.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := v.Run(context.Background()); err == nil {
+		t.Errorf("expected error when running the validator again")
+	}
+}
+
+func TestValidator_RunWithInvalidCRD(t *testing.T) {
+	root := testutil.WriteTree(t, map[string]string{
+		"crds/kustomization.yaml": "resources:\n  - crd.yaml\n",
+		"crds/crd.yaml":           "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: broken\nspec:\n  names: {}\n",
+		"app/kustomization.yaml":  "resources:\n  - cm.yaml\n",
+		"app/cm.yaml":             "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+	})
+	validator, err := schema.Kubernetes(schema.LatestKubernetesVersion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(WithPaths(root), WithSchema(validator, ""), WithBuilder(validate.EmbeddedBuilder{})).Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Summary.Total != 2 || r.Summary.Success != 1 || r.Summary.Error != 1 {
+		t.Errorf("expected the kustomization rendering the invalid CustomResourceDefinition to fail only, got: %+v", r.Summary)
+	}
+	var got []string
+	for _, finding := range r.Results[1].Findings {
+		if finding.FieldPath == "" {
+			got = append(got, finding.Rule+": "+finding.Message)
+		}
+	}
+	if want := []string{"schema: invalid CustomResourceDefinition: missing group or kind"}; !strings.HasSuffix(r.Results[1].Path, "crds") || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected findings %v in crds, got: %v in %s", want, got, r.Results[1].Path)
+	}
+}
+
+func TestValidator_RunWithInvalidAdmissionPolicy(t *testing.T) {
+	root := testutil.WriteTree(t, map[string]string{
+		"policies/kustomization.yaml": "resources:\n  - policy.yaml\n",
+		"policies/policy.yaml":        "apiVersion: admissionregistration.k8s.io/v1\nkind: ValidatingAdmissionPolicy\nmetadata:\n  name: broken\nspec:\n  validations: object.spec.replicas >= 2\n",
+		"app/kustomization.yaml":      "resources:\n  - cm.yaml\n",
+		"app/cm.yaml":                 "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+	})
+	admission, err := policy.NewAdmission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(WithPaths(root), WithAdmissionPolicies(admission), WithBuilder(validate.EmbeddedBuilder{})).Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (report.Summary{Total: 2, Success: 1, Error: 1, Findings: 1}); r.Summary != want {
+		t.Errorf("expected summary %+v, got: %+v", want, r.Summary)
+	}
+	if findings := r.Results[1].Findings; len(findings) != 1 || findings[0].Rule != policy.RuleAdmissionPolicy || findings[0].Name != "broken" || findings[0].Description == "" {
+		t.Errorf("expected admission policy finding on the invalid policy, got: %+v", findings)
+	}
+}
+
+func TestValidator_RunIncludeChangedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := testutil.WriteTree(t, map[string]string{
+		"base/kustomization.yaml":       "resources:\n  - cm.yaml\n",
+		"base/cm.yaml":                  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"overlays/a/kustomization.yaml": "namePrefix: a-\nresources:\n  - ../../base\n",
+		"overlays/b/kustomization.yaml": "namePrefix: b-\nresources:\n  - ../../base\n",
+		"other/kustomization.yaml":      "resources:\n  - cm.yaml\n",
+		"other/cm.yaml":                 "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n",
+	})
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.email=test@example.com", "-c", "user.name=test", "commit", "-q", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "base", "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the overlays are included, the changed base is not
+	selectDir := func(dir string) bool {
+		return strings.HasPrefix(dir, filepath.Join(root, "overlays")+string(filepath.Separator))
+	}
+	r, err := New(WithPaths(root), WithFilter(nil, selectDir), WithChangedSince("HEAD"), WithBuilder(validate.EmbeddedBuilder{})).Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", r.Warnings)
+	}
+	var built []string
+	for _, result := range r.Results {
+		built = append(built, result.Path)
+	}
+	if want := []string{filepath.Join(root, "overlays", "a"), filepath.Join(root, "overlays", "b")}; !reflect.DeepEqual(built, want) {
+		t.Errorf("expected the overlays of the changed base to be built, got: %v", built)
+	}
+	if want := []string{filepath.Join(root, "base"), filepath.Join(root, "other")}; !reflect.DeepEqual(r.Skipped, want) {
+		t.Errorf("expected the base and the unaffected kustomization to be skipped, got: %v", r.Skipped)
+	}
+}